@@ -0,0 +1,135 @@
+// Package alert sends structured JSON events about failures of the validator and the
+// publisher to the HTTP webhooks of the [alert] config, so that operators can be paged.
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sunriselayer/sunrise-data/config"
+)
+
+// Event types.
+const (
+	EventDeadlineMissed      = "deadline_missed"
+	EventProofFailed         = "proof_failed"
+	EventInvaliditySubmitted = "invalidity_submitted"
+	EventLowBalance          = "low_balance"
+	EventRpcOutage           = "rpc_outage"
+	EventIpfsOutage          = "ipfs_outage"
+	EventPublishFailed       = "publish_failed"
+)
+
+// EventTypes are the types of the events sent to webhooks.
+var EventTypes = []string{
+	EventDeadlineMissed,
+	EventProofFailed,
+	EventInvaliditySubmitted,
+	EventLowBalance,
+	EventRpcOutage,
+	EventIpfsOutage,
+	EventPublishFailed,
+}
+
+// Severities of events.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Event is the JSON body posted to webhooks.
+type Event struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	Severity string `json:"severity"`
+	// Resolved is set when the condition of a previous event of the same type is over.
+	Resolved bool   `json:"resolved,omitempty"`
+	Message  string `json:"message"`
+	// Source is the instance that sent the event.
+	Source string            `json:"source"`
+	Fields map[string]string `json:"fields,omitempty"`
+	// Suppressed is the number of events of this type dropped by the rate limit since the previous one.
+	Suppressed int       `json:"suppressed,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+var (
+	mu       sync.RWMutex
+	webhooks []*webhook
+	source   string
+)
+
+// Start starts a sender per webhook of the [alert] config and the outage checks of the
+// sunrised RPC and IPFS. Without webhooks, events are only logged.
+func Start(c config.Config) error {
+	conf := c.Alert
+	if len(conf.Webhooks) == 0 {
+		return nil
+	}
+
+	src := conf.Source
+	if src == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		src = hostname
+	}
+
+	hooks := []*webhook{}
+	for _, hookConf := range conf.Webhooks {
+		if hookConf.Url == "" {
+			return fmt.Errorf("url of an alert webhook is not configured")
+		}
+		for _, eventType := range hookConf.Events {
+			if !slices.Contains(EventTypes, eventType) {
+				return fmt.Errorf("unsupported alert event %q for %s, use one of %v", eventType, hookConf.Url, EventTypes)
+			}
+		}
+		hooks = append(hooks, newWebhook(hookConf, c))
+	}
+
+	mu.Lock()
+	webhooks = hooks
+	source = src
+	mu.Unlock()
+	for _, hook := range hooks {
+		go hook.run()
+	}
+	log.Info().Msgf("Alerts of %s are sent to %d webhooks", src, len(hooks))
+
+	startOutageChecks(c)
+	return nil
+}
+
+// Notify sends e to the webhooks routing its type. It does not block: the event is
+// dropped if a webhook is rate limited or its queue is full.
+func Notify(e Event) {
+	mu.RLock()
+	hooks := webhooks
+	e.Source = source
+	mu.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	e.Id = newEventId()
+	e.At = time.Now().UTC()
+	for _, hook := range hooks {
+		hook.enqueue(e)
+	}
+}
+
+func newEventId() string {
+	bz := make([]byte, 16)
+	rand.Read(bz)
+	return hex.EncodeToString(bz)
+}
@@ -0,0 +1,101 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sunriselayer/sunrise-data/config"
+	scontext "github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/protocols"
+)
+
+const (
+	defaultCheckInterval   = 60
+	defaultOutageThreshold = 3
+	// checkTimeout bounds a check, so that a hanging service counts as a failed check.
+	checkTimeout = 10 * time.Second
+)
+
+// outage tracks the consecutive failed checks of a service.
+type outage struct {
+	eventType string
+	service   string
+	check     func(ctx context.Context) error
+
+	failures int
+	down     bool
+	since    time.Time
+}
+
+// startOutageChecks checks the sunrised RPC and IPFS every check_interval, and sends an outage
+// event after outage_threshold failed checks in a row, then a resolved event once it is back.
+// Each service is checked by its own goroutine, so that a slow service does not delay the others.
+func startOutageChecks(c config.Config) {
+	interval := c.Alert.CheckInterval
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	threshold := c.Alert.OutageThreshold
+	if threshold <= 0 {
+		threshold = defaultOutageThreshold
+	}
+
+	outages := []*outage{
+		{eventType: EventRpcOutage, service: "sunrised RPC", check: func(ctx context.Context) error {
+			_, err := scontext.NodeClient.LatestBlockHeight(ctx)
+			return err
+		}},
+		{eventType: EventIpfsOutage, service: "IPFS", check: protocols.PingIpfs},
+	}
+	for _, o := range outages {
+		go func() {
+			ticker := time.NewTicker(time.Duration(interval) * time.Second)
+			for range ticker.C {
+				o.run(threshold)
+			}
+		}()
+	}
+}
+
+func (o *outage) run(threshold int) {
+	ctx, cancel := context.WithTimeout(scontext.Ctx, checkTimeout)
+	err := o.check(ctx)
+	cancel()
+	if err == nil {
+		if o.down {
+			log.Info().Msgf("%s is reachable again after %v", o.service, time.Since(o.since).Round(time.Second))
+			Notify(Event{
+				Type:     o.eventType,
+				Severity: SeverityInfo,
+				Resolved: true,
+				Message:  fmt.Sprintf("%s is reachable again", o.service),
+				Fields:   map[string]string{"down_since": o.since.Format(time.RFC3339)},
+			})
+		}
+		o.failures = 0
+		o.down = false
+		return
+	}
+
+	if o.failures == 0 {
+		o.since = time.Now().UTC()
+	}
+	o.failures++
+	if o.down || o.failures < threshold {
+		return
+	}
+	o.down = true
+	Notify(Event{
+		Type:     o.eventType,
+		Severity: SeverityCritical,
+		Message:  fmt.Sprintf("%s is unreachable: %s", o.service, err),
+		Fields: map[string]string{
+			"error":      err.Error(),
+			"down_since": o.since.Format(time.RFC3339),
+			"failures":   fmt.Sprint(o.failures),
+		},
+	})
+}
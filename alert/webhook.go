@@ -0,0 +1,217 @@
+package alert
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sunriselayer/sunrise-data/config"
+)
+
+const (
+	// EventHeader is the type of the posted event.
+	EventHeader = "X-Sunrise-Data-Event"
+	// TimestampHeader is the unix time when the event was posted, which is part of the signature.
+	TimestampHeader = "X-Sunrise-Data-Timestamp"
+	// SignatureHeader is "sha256=" followed by the hex HMAC-SHA256 of the timestamp, ".", and the body,
+	// keyed with the secret of the webhook. It is only set when the webhook has a secret.
+	SignatureHeader = "X-Sunrise-Data-Signature"
+
+	defaultRateLimit     = 10
+	defaultRateInterval  = 600
+	defaultMaxRetries    = 5
+	defaultRetryInterval = 2
+	defaultTimeout       = 10
+
+	// maxQueuedEvents is the number of events waiting for a webhook before new ones are dropped.
+	maxQueuedEvents = 100
+)
+
+// Sign returns the hex HMAC-SHA256 of timestamp and body keyed with secret, as sent in SignatureHeader.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhook posts the events routed to an endpoint, one at a time.
+type webhook struct {
+	url    string
+	secret string
+	// events are the routed event types, every type if empty.
+	events        []string
+	rateLimit     int
+	rateLimits    map[string]int
+	rateInterval  time.Duration
+	maxRetries    int
+	retryInterval time.Duration
+	client        *http.Client
+	queue         chan Event
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+// rateWindow counts the events of a type sent in the current rate interval.
+type rateWindow struct {
+	start      time.Time
+	sent       int
+	suppressed int
+}
+
+func newWebhook(hook config.AlertWebhook, c config.Config) *webhook {
+	conf := c.Alert
+	rateLimit := conf.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = defaultRateLimit
+	}
+	rateInterval := conf.RateInterval
+	if rateInterval <= 0 {
+		rateInterval = defaultRateInterval
+	}
+	maxRetries := conf.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	retryInterval := conf.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = defaultRetryInterval
+	}
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &webhook{
+		url:           hook.Url,
+		secret:        hook.Secret,
+		events:        hook.Events,
+		rateLimit:     rateLimit,
+		rateLimits:    conf.RateLimits,
+		rateInterval:  time.Duration(rateInterval) * time.Second,
+		maxRetries:    maxRetries,
+		retryInterval: time.Duration(retryInterval) * time.Second,
+		client:        &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		queue:         make(chan Event, maxQueuedEvents),
+		windows:       map[string]*rateWindow{},
+	}
+}
+
+// enqueue queues e if its type is routed to the webhook and not rate limited.
+func (w *webhook) enqueue(e Event) {
+	if len(w.events) > 0 && !slices.Contains(w.events, e.Type) {
+		return
+	}
+	// a resolved event is always sent, so that the receiver does not keep an alert open
+	if !e.Resolved {
+		var ok bool
+		if e.Suppressed, ok = w.allow(e.Type); !ok {
+			log.Debug().Msgf("Alert %s to %s is rate limited", e.Type, w.url)
+			return
+		}
+	}
+	select {
+	case w.queue <- e:
+	default:
+		log.Warn().Msgf("Alert queue of %s is full, dropping %s: %s", w.url, e.Type, e.Message)
+	}
+}
+
+// allow counts an event of eventType against its rate limit. It returns false if the event
+// has to be dropped, or the number of events dropped since the previous one.
+func (w *webhook) allow(eventType string) (int, bool) {
+	limit := w.rateLimit
+	if l, ok := w.rateLimits[eventType]; ok {
+		limit = l
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	window, ok := w.windows[eventType]
+	if !ok || time.Since(window.start) >= w.rateInterval {
+		suppressed := 0
+		if ok {
+			suppressed = window.suppressed
+		}
+		window = &rateWindow{start: time.Now(), suppressed: suppressed}
+		w.windows[eventType] = window
+	}
+	if window.sent >= limit {
+		window.suppressed++
+		return 0, false
+	}
+	window.sent++
+	suppressed := window.suppressed
+	window.suppressed = 0
+	return suppressed, true
+}
+
+func (w *webhook) run() {
+	for e := range w.queue {
+		w.deliver(e)
+	}
+}
+
+// deliver posts e, retrying failed posts with a doubling interval up to max_retries times.
+func (w *webhook) deliver(e Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Error().Msgf("Failed to encode alert %s: %s", e.Type, err)
+		return
+	}
+
+	backoff := w.retryInterval
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(e.Type, body)
+		if err == nil {
+			log.Debug().Msgf("Sent alert %s %s to %s", e.Type, e.Id, w.url)
+			return
+		}
+		if !retry || attempt >= w.maxRetries {
+			log.Error().Msgf("Failed to send alert %s to %s after %d attempts: %s", e.Type, w.url, attempt+1, err)
+			return
+		}
+		log.Warn().Msgf("Failed to send alert %s to %s, retrying in %v: %s", e.Type, w.url, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends body once. It returns true with the error if the post can be retried.
+func (w *webhook) post(eventType string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(TimestampHeader, timestamp)
+	if w.secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, timestamp, body))
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return false, nil
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %s", res.Status)
+	default:
+		return false, fmt.Errorf("webhook returned %s", res.Status)
+	}
+}
@@ -0,0 +1,106 @@
+package alert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sunriselayer/sunrise-data/config"
+)
+
+func TestSign(t *testing.T) {
+	signature := Sign("secret", "1700000000", []byte(`{"type":"rpc_outage"}`))
+	if signature != "ccf973f0ff0ecba754c0d4c537ee538e94e20694a9cf9cf05e9f3de2b6f64da7" {
+		t.Fatalf("unexpected signature %s", signature)
+	}
+	if Sign("secret", "1700000001", []byte(`{"type":"rpc_outage"}`)) == signature {
+		t.Fatal("signature does not cover the timestamp")
+	}
+	if Sign("other", "1700000000", []byte(`{"type":"rpc_outage"}`)) == signature {
+		t.Fatal("signature does not depend on the secret")
+	}
+}
+
+func testWebhook(url string) *webhook {
+	var c config.Config
+	c.Alert.RateLimit = 2
+	c.Alert.RateLimits = map[string]int{EventLowBalance: 1}
+	return newWebhook(config.AlertWebhook{Url: url, Secret: "secret"}, c)
+}
+
+func TestAllow(t *testing.T) {
+	w := testWebhook("")
+
+	for i := 0; i < 2; i++ {
+		if suppressed, ok := w.allow(EventRpcOutage); !ok || suppressed != 0 {
+			t.Fatalf("event %d: allowed %v with %d suppressed", i, ok, suppressed)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := w.allow(EventRpcOutage); ok {
+			t.Fatalf("event over the rate limit is allowed")
+		}
+	}
+	// the rate limit of a type is counted apart from the others
+	if _, ok := w.allow(EventLowBalance); !ok {
+		t.Fatal("first event of another type is not allowed")
+	}
+	if _, ok := w.allow(EventLowBalance); ok {
+		t.Fatal("event over the rate limit of its type is allowed")
+	}
+
+	// the events suppressed in a window are reported by the first event of the next one
+	w.windows[EventRpcOutage].start = time.Now().Add(-w.rateInterval)
+	if suppressed, ok := w.allow(EventRpcOutage); !ok || suppressed != 3 {
+		t.Fatalf("first event of the next window: allowed %v with %d suppressed, expected 3", ok, suppressed)
+	}
+	if suppressed, ok := w.allow(EventRpcOutage); !ok || suppressed != 0 {
+		t.Fatalf("second event of the next window: allowed %v with %d suppressed", ok, suppressed)
+	}
+	if _, ok := w.allow(EventRpcOutage); ok {
+		t.Fatal("event over the rate limit of the next window is allowed")
+	}
+}
+
+func TestPostRetry(t *testing.T) {
+	tests := []struct {
+		status    int
+		retry     bool
+		expectErr bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusNoContent, false, false},
+		{http.StatusTooManyRequests, true, true},
+		{http.StatusInternalServerError, true, true},
+		{http.StatusServiceUnavailable, true, true},
+		{http.StatusBadRequest, false, true},
+		{http.StatusUnauthorized, false, true},
+		{http.StatusNotFound, false, true},
+	}
+	body := []byte(`{"type":"rpc_outage"}`)
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(EventHeader) != EventRpcOutage {
+				t.Errorf("unexpected event header %q", r.Header.Get(EventHeader))
+			}
+			expected := "sha256=" + Sign("secret", r.Header.Get(TimestampHeader), body)
+			if r.Header.Get(SignatureHeader) != expected {
+				t.Errorf("unexpected signature header %q", r.Header.Get(SignatureHeader))
+			}
+			rw.WriteHeader(tt.status)
+		}))
+		retry, err := testWebhook(server.URL).post(EventRpcOutage, body)
+		server.Close()
+		if retry != tt.retry || (err != nil) != tt.expectErr {
+			t.Errorf("status %d: retry %v, err %v", tt.status, retry, err)
+		}
+	}
+
+	// an unreachable webhook is retried
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	if retry, err := testWebhook(server.URL).post(EventRpcOutage, body); !retry || err == nil {
+		t.Fatalf("unreachable webhook: retry %v, err %v", retry, err)
+	}
+}
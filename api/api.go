@@ -21,6 +21,11 @@ type PublishRequest struct {
 	DataShardCount   int    `json:"data_shard_count"`
 	ParityShardCount int    `json:"parity_shard_count"`
 	Protocol         string `json:"protocol"`
+	// ReplicationTargets are the IPFS API URLs that pin the published data before it is
+	// submitted, within ReplicationTimeout seconds. They are set by the Optimism and Rollkit
+	// servers from their config only.
+	ReplicationTargets []string `json:"-"`
+	ReplicationTimeout int      `json:"-"`
 }
 
 type PublishResponse struct {
@@ -38,6 +43,7 @@ func Handle() {
 
 	r.HandleFunc("/shard-hashes", ShardHashes).Methods("GET")
 	r.HandleFunc("/blob", GetBlob).Methods("GET")
+	r.HandleFunc("/balance", Balance).Methods("GET")
 
 	log.Info().Msgf("Running Publisher API on localhost: %d", scontext.Config.Api.Port)
 	http.ListenAndServe(fmt.Sprintf(":%d", scontext.Config.Api.Port), r)
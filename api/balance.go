@@ -0,0 +1,13 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sunriselayer/sunrise-data/balance"
+)
+
+func Balance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balance.Statuses())
+}
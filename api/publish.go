@@ -4,17 +4,37 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/sunriselayer/sunrise/x/da/erasurecoding"
 	"github.com/sunriselayer/sunrise/x/da/types"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/sunriselayer/sunrise-data/alert"
+	"github.com/sunriselayer/sunrise-data/balance"
+	"github.com/sunriselayer/sunrise-data/consts"
 	"github.com/sunriselayer/sunrise-data/context"
 	"github.com/sunriselayer/sunrise-data/protocols"
 	"github.com/sunriselayer/sunrise-data/utils"
 )
 
+const (
+	defaultPublishFailureThreshold = 3
+	defaultReplicationTimeout      = 120
+)
+
+// ErrInvalidPublishRequest is wrapped by the errors of requests that cannot be published as they are.
+// They are not counted as failed publish jobs.
+var ErrInvalidPublishRequest = errors.New("invalid publish request")
+
+func invalidRequest(err error) error {
+	return fmt.Errorf("%w: %w", ErrInvalidPublishRequest, err)
+}
+
 func Publish(w http.ResponseWriter, r *http.Request) {
 	var req PublishRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -35,17 +55,29 @@ func Publish(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
+// PublishData publishes the blob of req and alerts when publish jobs keep failing.
 func PublishData(req PublishRequest) (PublishResponse, error) {
+	res, err := publishData(req)
+	recordPublish(req.Protocol, err)
+	return res, err
+}
+
+func publishData(req PublishRequest) (PublishResponse, error) {
+	if err := balance.CheckPublish(); err != nil {
+		log.Err(err).Msg("Refused publish request")
+		return PublishResponse{}, err
+	}
+
 	blobBytes, err := base64.StdEncoding.DecodeString(req.Blob)
 	if err != nil {
 		log.Err(err).Msg("Failed to decode blob")
-		return PublishResponse{}, err
+		return PublishResponse{}, invalidRequest(err)
 	}
 
 	publishProtocol, err := protocols.GetPublishProtocol(req.Protocol)
 	if err != nil {
 		log.Err(err).Msg("Failed to get publish protocol")
-		return PublishResponse{}, err
+		return PublishResponse{}, invalidRequest(err)
 	}
 
 	recoveredDataHash, err := utils.HashSha256(blobBytes)
@@ -61,21 +93,21 @@ func PublishData(req PublishRequest) (PublishResponse, error) {
 	}
 	if queryParamResponse.Params.MinShardCount > uint64(req.DataShardCount+req.ParityShardCount) {
 		log.Error().Msg("DataShardCount + ParityShardCount is smaller than Min_ShardCount")
-		return PublishResponse{}, errors.New("DataShardCount + ParityShardCount is smaller than Min_ShardCount")
+		return PublishResponse{}, invalidRequest(errors.New("DataShardCount + ParityShardCount is smaller than Min_ShardCount"))
 	}
 	if queryParamResponse.Params.MaxShardCount < uint64(req.DataShardCount+req.ParityShardCount) {
 		log.Error().Msg("DataShardCount + ParityShardCount is bigger than Max_ShardCount")
-		return PublishResponse{}, errors.New("DataShardCount + ParityShardCount is bigger than Max_ShardCount")
+		return PublishResponse{}, invalidRequest(errors.New("DataShardCount + ParityShardCount is bigger than Max_ShardCount"))
 	}
 
 	shardSize, _, shards, err := erasurecoding.ErasureCode(blobBytes, req.DataShardCount, req.ParityShardCount)
 	if err != nil {
 		log.Err(err).Msg("Failed to erasure code")
-		return PublishResponse{}, err
+		return PublishResponse{}, invalidRequest(err)
 	}
 	if queryParamResponse.Params.MaxShardSize < shardSize {
 		log.Error().Msg("ShardSize is bigger than Max_ShardSize")
-		return PublishResponse{}, errors.New("ShardSize is bigger than Max_ShardSize")
+		return PublishResponse{}, invalidRequest(errors.New("ShardSize is bigger than Max_ShardSize"))
 	}
 	shardUris, err := publishProtocol.PublishShards(shards)
 	if err != nil {
@@ -84,6 +116,7 @@ func PublishData(req PublishRequest) (PublishResponse, error) {
 	}
 	metadata := types.Metadata{
 		ShardSize:         shardSize,
+		ParityShardCount:  uint64(req.ParityShardCount),
 		RecoveredDataHash: recoveredDataHash,
 		RecoveredDataSize: uint64(len(blobBytes)),
 		ShardUris:         shardUris,
@@ -99,6 +132,10 @@ func PublishData(req PublishRequest) (PublishResponse, error) {
 		log.Err(err).Msg("Failed to publish metadata")
 		return PublishResponse{}, err
 	}
+	if err := replicate(req, append(shardUris, metadataUri)); err != nil {
+		log.Err(err).Msg("Failed to replicate data")
+		return PublishResponse{}, err
+	}
 
 	// Define a message to create a post
 	msg := &types.MsgPublishData{
@@ -121,3 +158,82 @@ func PublishData(req PublishRequest) (PublishResponse, error) {
 		MetadataUri: metadataUri,
 	}, nil
 }
+
+// PublishProtocol returns protocol, or ipfs if it is empty, and checks that it supports replicationTargets.
+func PublishProtocol(protocol string, replicationTargets []string) (string, error) {
+	if protocol == "" {
+		protocol = consts.IPFS_PROTOCOL
+	}
+	if _, err := protocols.GetPublishProtocol(protocol); err != nil {
+		return "", fmt.Errorf("%w: %s", err, protocol)
+	}
+	if len(replicationTargets) > 0 && protocol != consts.IPFS_PROTOCOL {
+		return "", fmt.Errorf("replication targets are not supported by protocol %s", protocol)
+	}
+	return protocol, nil
+}
+
+// replicate pins uris on the replication targets of req concurrently.
+func replicate(req PublishRequest, uris []string) error {
+	if len(req.ReplicationTargets) == 0 {
+		return nil
+	}
+	if req.Protocol != consts.IPFS_PROTOCOL {
+		return invalidRequest(fmt.Errorf("replication targets are not supported by protocol %s", req.Protocol))
+	}
+	timeout := req.ReplicationTimeout
+	if timeout <= 0 {
+		timeout = defaultReplicationTimeout
+	}
+	g := new(errgroup.Group)
+	for _, target := range req.ReplicationTargets {
+		g.Go(func() error {
+			return protocols.ReplicateIpfs(target, uris, time.Duration(timeout)*time.Second)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	log.Info().Msgf("Replicated %d uris to %d IPFS nodes", len(uris), len(req.ReplicationTargets))
+	return nil
+}
+
+// consecutivePublishFailures counts the publish jobs failed since the last published data.
+var consecutivePublishFailures atomic.Int64
+
+// recordPublish records the result of a publish job, and sends an alert for every failure
+// once publish_failure_threshold jobs in a row have failed. Invalid requests and refusals
+// for a low balance, which has its own alert, are not failed jobs.
+func recordPublish(protocol string, err error) {
+	if errors.Is(err, ErrInvalidPublishRequest) || errors.Is(err, balance.ErrLowBalance) {
+		return
+	}
+	if err == nil {
+		consecutivePublishFailures.Store(0)
+		return
+	}
+	failures := consecutivePublishFailures.Add(1)
+	threshold := context.Config.Alert.PublishFailureThreshold
+	if threshold <= 0 {
+		threshold = defaultPublishFailureThreshold
+	}
+	if failures < int64(threshold) {
+		return
+	}
+	alert.Notify(alert.Event{
+		Type:     alert.EventPublishFailed,
+		Severity: alert.SeverityCritical,
+		Message:  fmt.Sprintf("%d publish jobs failed in a row: %s", failures, err),
+		Fields: map[string]string{
+			"protocol":             protocol,
+			"consecutive_failures": fmt.Sprint(failures),
+			"error":                err.Error(),
+		},
+	})
+}
+
+// publishFailed responds to a failed publish job with err.
+func publishFailed(w http.ResponseWriter, protocol string, err error, code int) {
+	recordPublish(protocol, err)
+	http.Error(w, err.Error(), code)
+}
@@ -10,12 +10,18 @@ import (
 	"github.com/sunriselayer/sunrise/x/da/erasurecoding"
 	"github.com/sunriselayer/sunrise/x/da/types"
 
+	"github.com/sunriselayer/sunrise-data/balance"
 	"github.com/sunriselayer/sunrise-data/context"
 	"github.com/sunriselayer/sunrise-data/protocols"
 	"github.com/sunriselayer/sunrise-data/utils"
 )
 
 func PublishFile(w http.ResponseWriter, r *http.Request) {
+	if err := balance.CheckPublish(); err != nil {
+		publishFailed(w, "", err, http.StatusServiceUnavailable)
+		return
+	}
+
 	r.ParseMultipartForm(10 << 20)
 
 	fileName := r.FormValue("file_name")
@@ -61,7 +67,7 @@ func PublishFile(w http.ResponseWriter, r *http.Request) {
 
 	queryParamResponse, err := context.QueryClient.Params(context.Ctx, &types.QueryParamsRequest{})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		publishFailed(w, protocol, err, http.StatusBadRequest)
 		return
 	}
 	if queryParamResponse.Params.MinShardCount > uint64(dataShardCount+parityShardCount) {
@@ -84,25 +90,26 @@ func PublishFile(w http.ResponseWriter, r *http.Request) {
 	}
 	shardUris, err := publishProtocol.PublishShards(shards)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		publishFailed(w, protocol, err, http.StatusBadRequest)
 		return
 	}
 	metadata := types.Metadata{
 		ShardSize:         shardSize,
+		ParityShardCount:  uint64(parityShardCount),
 		RecoveredDataHash: recoveredDataHash,
 		RecoveredDataSize: uint64(len(fileBytes)),
 		ShardUris:         shardUris,
 	}
 	metadataBytes, err := metadata.Marshal()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		publishFailed(w, protocol, err, http.StatusBadRequest)
 		return
 	}
 
 	metadataUri := ""
 	metadataUri, err = publishProtocol.PublishMetadata(metadataBytes)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		publishFailed(w, protocol, err, http.StatusBadRequest)
 		return
 	}
 
@@ -118,10 +125,11 @@ func PublishFile(w http.ResponseWriter, r *http.Request) {
 	// to create a post store response in txResp
 	txResp, err := context.NodeClient.BroadcastTx(context.Ctx, context.Account, msg)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		publishFailed(w, protocol, err, http.StatusBadRequest)
 		return
 	}
 	log.Info().Msgf("TxHash: %s", txResp.TxHash)
+	recordPublish(protocol, nil)
 	// Print response from broadcasting a transaction
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(PublishResponse{
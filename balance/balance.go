@@ -0,0 +1,215 @@
+package balance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/sunriselayer/sunrise-data/alert"
+	"github.com/sunriselayer/sunrise-data/context"
+)
+
+const (
+	RolePublisher = "publisher"
+	RoleDeputy    = "deputy"
+
+	defaultCheckInterval = 60
+)
+
+// ErrLowBalance is returned when a publish job is refused because the publisher account runs low on funds.
+var ErrLowBalance = errors.New("account balance is below the configured threshold")
+
+// Status is the last known balance of a watched account.
+type Status struct {
+	Role     string    `json:"role"`
+	Address  string    `json:"address"`
+	Balance  sdk.Coins `json:"balance"`
+	FeePerTx sdk.Coins `json:"fee_per_tx"`
+	// RemainingTxs is the estimated number of txs the balance can pay fees for.
+	// It is nil when no fee is configured.
+	RemainingTxs *uint64   `json:"remaining_txs,omitempty"`
+	Low          bool      `json:"low"`
+	Error        string    `json:"error,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Watcher periodically queries the bank balance of an account.
+type Watcher struct {
+	role    string
+	address string
+	fees    sdk.Coins
+
+	mu     sync.RWMutex
+	status Status
+}
+
+var (
+	watchersMu sync.RWMutex
+	watchers   = map[string]*Watcher{}
+)
+
+// Start starts a watcher for the account with the given role.
+// fees is the fee paid per tx (e.g. publish_fees) and is used to estimate the remaining txs.
+func Start(role string, address string, fees string) (*Watcher, error) {
+	feeCoins, err := sdk.ParseCoinsNormalized(fees)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fees %q: %w", fees, err)
+	}
+
+	w := &Watcher{
+		role:    role,
+		address: address,
+		fees:    feeCoins,
+		status: Status{
+			Role:     role,
+			Address:  address,
+			FeePerTx: feeCoins,
+		},
+	}
+
+	watchersMu.Lock()
+	watchers[role] = w
+	watchersMu.Unlock()
+
+	interval := context.Config.Balance.CheckInterval
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	log.Info().Msgf("Balance of %s %s is checked every %v sec", role, address, interval)
+
+	w.Check()
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	go func() {
+		for range ticker.C {
+			w.Check()
+		}
+	}()
+	return w, nil
+}
+
+// Get returns the watcher of the given role, or nil if the role is not watched.
+func Get(role string) *Watcher {
+	watchersMu.RLock()
+	defer watchersMu.RUnlock()
+	return watchers[role]
+}
+
+// Statuses returns the status of every watched account.
+func Statuses() []Status {
+	watchersMu.RLock()
+	defer watchersMu.RUnlock()
+
+	statuses := []Status{}
+	for _, w := range watchers {
+		statuses = append(statuses, w.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Role < statuses[j].Role
+	})
+	return statuses
+}
+
+// CheckPublish returns ErrLowBalance when refuse_publish_when_low is enabled and the
+// publisher account is low on funds. It is called before any shard is uploaded.
+func CheckPublish() error {
+	if !context.Config.Balance.RefusePublishWhenLow {
+		return nil
+	}
+	w := Get(RolePublisher)
+	if w == nil {
+		return nil
+	}
+	status := w.Status()
+	if !status.Low {
+		return nil
+	}
+	return fmt.Errorf("%w: publisher %s has %s left", ErrLowBalance, status.Address, status.Balance)
+}
+
+// Status returns the last known balance.
+func (w *Watcher) Status() Status {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.status
+}
+
+// Check queries the balance and logs a warning if it is below the threshold.
+func (w *Watcher) Check() {
+	balance, err := context.NodeClient.BankBalances(context.Ctx, w.address, nil)
+	if err != nil {
+		log.Error().Msgf("Failed to query balance of %s %s: %s", w.role, w.address, err)
+		w.mu.Lock()
+		w.status.Error = err.Error()
+		w.mu.Unlock()
+		return
+	}
+
+	status := Status{
+		Role:      w.role,
+		Address:   w.address,
+		Balance:   balance,
+		FeePerTx:  w.fees,
+		UpdatedAt: time.Now(),
+	}
+	if remaining, ok := EstimateRemainingTxs(balance, w.fees); ok {
+		status.RemainingTxs = &remaining
+		status.Low = remaining < context.Config.Balance.WarnRemainingTxs
+	}
+
+	w.mu.Lock()
+	wasLow := w.status.Low
+	w.status = status
+	w.mu.Unlock()
+
+	// an alert is sent when the balance becomes low, and when it is funded again
+	if status.Low != wasLow {
+		notifyLowBalance(status)
+	}
+	if status.Low {
+		log.Warn().Msgf("Low balance of %s %s: %s, about %d txs left. Please send funds to this account", w.role, w.address, balance, *status.RemainingTxs)
+	} else {
+		log.Debug().Msgf("Balance of %s %s: %s", w.role, w.address, balance)
+	}
+}
+
+func notifyLowBalance(status Status) {
+	event := alert.Event{
+		Type:     alert.EventLowBalance,
+		Severity: alert.SeverityWarning,
+		Resolved: !status.Low,
+		Message:  fmt.Sprintf("Balance of %s %s is %s", status.Role, status.Address, status.Balance),
+		Fields: map[string]string{
+			"role":    status.Role,
+			"address": status.Address,
+			"balance": status.Balance.String(),
+		},
+	}
+	if status.Low {
+		event.Message = fmt.Sprintf("Low balance of %s %s: %s, about %d txs left", status.Role, status.Address, status.Balance, *status.RemainingTxs)
+		event.Fields["remaining_txs"] = fmt.Sprint(*status.RemainingTxs)
+	}
+	alert.Notify(event)
+}
+
+// EstimateRemainingTxs returns how many txs paying fees the balance can cover.
+// It returns false if fees is empty.
+func EstimateRemainingTxs(balance sdk.Coins, fees sdk.Coins) (uint64, bool) {
+	if fees.IsZero() {
+		return 0, false
+	}
+
+	remaining := uint64(math.MaxUint64)
+	for _, fee := range fees {
+		count := balance.AmountOf(fee.Denom).Quo(fee.Amount)
+		if count.IsUint64() && count.Uint64() < remaining {
+			remaining = count.Uint64()
+		}
+	}
+	return remaining, true
+}
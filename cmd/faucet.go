@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/sunriselayer/sunrise-data/config"
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/faucet"
+)
+
+var faucetCmd = &cobra.Command{
+	Use:   "faucet",
+	Short: "Start a stand-in faucet for local networks",
+	Long:  `This command starts a faucet that sends funds from the [faucet] account to publisher and deputy accounts. Use it only on local and test networks.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := config.LoadConfig()
+		if err != nil {
+			log.Error().Msgf("Failed to load config: %s", err)
+			return err
+		}
+
+		if err = context.GetFaucetContext(*config); err != nil {
+			log.Error().Msgf("Failed to connect to sunrised RPC: %s", err)
+			return err
+		}
+
+		if err := faucet.Serve(); err != nil {
+			log.Error().Msgf("Faucet stopped: %s", err)
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(faucetCmd)
+}
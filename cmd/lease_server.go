@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/sunriselayer/sunrise-data/config"
+	"github.com/sunriselayer/sunrise-data/leader"
+)
+
+var leaseServerCmd = &cobra.Command{
+	Use:   "lease-server",
+	Short: "Start a stand-in lease server for leader election on local networks",
+	Long:  `This command starts a lease server for validator instances with the http leader backend. Leases are kept in memory, use it only on local and test networks.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := config.LoadConfig()
+		if err != nil {
+			log.Error().Msgf("Failed to load config: %s", err)
+			return err
+		}
+
+		if err := leader.Serve(config.Leader.Port); err != nil {
+			log.Error().Msgf("Lease server stopped: %s", err)
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(leaseServerCmd)
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/sunriselayer/sunrise-data/alert"
+	"github.com/sunriselayer/sunrise-data/balance"
 	"github.com/sunriselayer/sunrise-data/config"
 	appctx "github.com/sunriselayer/sunrise-data/context"
 	"github.com/sunriselayer/sunrise-data/optimism"
@@ -30,6 +32,16 @@ var optimismCmd = &cobra.Command{
 			return err
 		}
 
+		if err := alert.Start(*config); err != nil {
+			log.Error().Msgf("Failed to start alerts: %s", err)
+			return err
+		}
+
+		if _, err := balance.Start(balance.RolePublisher, appctx.Addr, config.Publish.PublishFees); err != nil {
+			log.Error().Msgf("Failed to start balance watcher: %s", err)
+			return err
+		}
+
 		if err := optimism.StartDAServer(); err != nil {
 			log.Error().Msgf("Failed to start Optimism DA Server: %s", err)
 			return err
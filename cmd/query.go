@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/spf13/cobra"
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+
+	"github.com/sunriselayer/sunrise-data/config"
+	"github.com/sunriselayer/sunrise-data/context"
+)
+
+const (
+	outputTable = "table"
+	outputJson  = "json"
+
+	// values longer than this are shortened in table output
+	maxTableValueLength = 64
+)
+
+var publishedDataColumns = []string{"metadata_uri", "status", "publisher", "timestamp"}
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Query the DA module",
+	Long:  `This command queries the DA module state through the sunrised_rpc in config.toml.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output != outputTable && output != outputJson {
+			return fmt.Errorf("unsupported output %q, use %q or %q", output, outputTable, outputJson)
+		}
+
+		config, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if err := context.GetQueryContext(*config); err != nil {
+			return fmt.Errorf("failed to connect to sunrised RPC: %w", err)
+		}
+		return nil
+	},
+}
+
+var queryParamsCmd = &cobra.Command{
+	Use:   "params",
+	Short: "Show the DA module params",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res, err := context.QueryClient.Params(cmd.Context(), &datypes.QueryParamsRequest{})
+		if err != nil {
+			return err
+		}
+		return printQueryResult(cmd, res, "params")
+	},
+}
+
+var queryPublishedDataCmd = &cobra.Command{
+	Use:   "published-data [metadata_uri]",
+	Short: "Show a published data, or list published data when no metadata_uri is given",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			res, err := context.QueryClient.PublishedData(cmd.Context(), &datypes.QueryPublishedDataRequest{MetadataUri: args[0]})
+			if err != nil {
+				return err
+			}
+			return printQueryResult(cmd, res, "data")
+		}
+
+		statusFlag, _ := cmd.Flags().GetString("status")
+		limit, _ := cmd.Flags().GetUint64("limit")
+		pageKey, _ := cmd.Flags().GetString("page-key")
+
+		var status *datypes.Status
+		if statusFlag != "" {
+			s, err := parseStatus(statusFlag)
+			if err != nil {
+				return err
+			}
+			status = &s
+		}
+		key, err := base64.StdEncoding.DecodeString(pageKey)
+		if err != nil {
+			return fmt.Errorf("invalid page-key: %w", err)
+		}
+
+		if status == nil {
+			res, err := context.QueryClient.AllPublishedData(cmd.Context(), &datypes.QueryAllPublishedDataRequest{
+				Pagination: &query.PageRequest{Key: key, Limit: limit},
+			})
+			if err != nil {
+				return err
+			}
+			return printQueryList(cmd, res, "data", publishedDataColumns)
+		}
+
+		// the status is filtered on the client, so pages are requested until limit matches are found.
+		// Each page asks for the missing matches only, so that next_key never skips unlisted data.
+		if limit == 0 {
+			limit = query.DefaultLimit
+		}
+		matches := []datypes.PublishedData{}
+		var res *datypes.QueryAllPublishedDataResponse
+		for {
+			res, err = context.QueryClient.AllPublishedData(cmd.Context(), &datypes.QueryAllPublishedDataRequest{
+				Pagination: &query.PageRequest{Key: key, Limit: limit - uint64(len(matches))},
+			})
+			if err != nil {
+				return err
+			}
+			for _, data := range res.Data {
+				if data.Status == *status {
+					matches = append(matches, data)
+				}
+			}
+			if uint64(len(matches)) >= limit || res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+				break
+			}
+			key = res.Pagination.NextKey
+		}
+		res.Data = matches
+		return printQueryList(cmd, res, "data", publishedDataColumns)
+	},
+}
+
+var queryValidityProofCmd = &cobra.Command{
+	Use:   "validity-proof [metadata_uri] [validator_address]",
+	Short: "Show the validity proof of a validator for a published data",
+	Long:  `Show the validity proof of a validator for a published data. validator_address defaults to the first validator in config.toml.`,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		validatorAddress := defaultValidatorAddress()
+		if len(args) == 2 {
+			validatorAddress = args[1]
+		}
+		res, err := context.QueryClient.ValidityProof(cmd.Context(), &datypes.QueryValidityProofRequest{
+			MetadataUri:      args[0],
+			ValidatorAddress: validatorAddress,
+		})
+		if err != nil {
+			return err
+		}
+		return printQueryResult(cmd, res, "proof")
+	},
+}
+
+var queryProofDeputyCmd = &cobra.Command{
+	Use:   "proof-deputy [validator_address]",
+	Short: "Show the proof deputy of a validator",
+	Long:  `Show the proof deputy of a validator. validator_address defaults to the first validator in config.toml.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		validatorAddress := defaultValidatorAddress()
+		if len(args) == 1 {
+			validatorAddress = args[0]
+		}
+		res, err := context.QueryClient.ProofDeputy(cmd.Context(), &datypes.QueryProofDeputyRequest{ValidatorAddress: validatorAddress})
+		if err != nil {
+			return err
+		}
+		return printQueryResult(cmd, res, "")
+	},
+}
+
+var queryZkpProofThresholdCmd = &cobra.Command{
+	Use:   "zkp-proof-threshold [shard_count]",
+	Short: "Show the number of shard proofs required from a validator",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shardCount, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid shard_count: %w", err)
+		}
+		res, err := context.QueryClient.ZkpProofThreshold(cmd.Context(), &datypes.QueryZkpProofThresholdRequest{ShardCount: shardCount})
+		if err != nil {
+			return err
+		}
+		return printQueryResult(cmd, res, "")
+	},
+}
+
+var queryInvalidityCmd = &cobra.Command{
+	Use:   "invalidity [metadata_uri] [sender_address]",
+	Short: "Show the invalidity submitted by an address for a published data",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res, err := context.QueryClient.Invalidity(cmd.Context(), &datypes.QueryInvalidityRequest{
+			MetadataUri:   args[0],
+			SenderAddress: args[1],
+		})
+		if err != nil {
+			return err
+		}
+		return printQueryResult(cmd, res, "invalidity")
+	},
+}
+
+// defaultValidatorAddress returns the address of the first validator in config.toml.
+func defaultValidatorAddress() string {
+	if pairs := context.Config.ValidatorPairs(); len(pairs) > 0 {
+		return pairs[0].ValidatorAddress
+	}
+	return ""
+}
+
+// parseStatus accepts a status as "challenging", "STATUS_CHALLENGING" or its number.
+func parseStatus(s string) (datypes.Status, error) {
+	if n, err := strconv.ParseInt(s, 10, 32); err == nil {
+		if _, ok := datypes.Status_name[int32(n)]; !ok {
+			return 0, fmt.Errorf("unknown status %d", n)
+		}
+		return datypes.Status(n), nil
+	}
+	name := strings.ToUpper(strings.ReplaceAll(s, "-", "_"))
+	if !strings.HasPrefix(name, "STATUS_") {
+		name = "STATUS_" + name
+	}
+	value, ok := datypes.Status_value[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown status %q", s)
+	}
+	return datypes.Status(value), nil
+}
+
+// toJsonMap encodes res with the chain codec and decodes it into a generic map,
+// so that table output shows the same field names as json output.
+func toJsonMap(res proto.Message) ([]byte, map[string]any, error) {
+	bz, err := context.NodeClient.Context().Codec.MarshalJSON(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(bz, &m); err != nil {
+		return nil, nil, err
+	}
+	return bz, m, nil
+}
+
+// printQueryResult prints res as json, or the object under field as a key/value table.
+func printQueryResult(cmd *cobra.Command, res proto.Message, field string) error {
+	bz, m, err := toJsonMap(res)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if output, _ := cmd.Flags().GetString("output"); output == outputJson {
+		_, err := fmt.Fprintln(out, string(bz))
+		return err
+	}
+
+	obj := m
+	if field != "" {
+		if inner, ok := m[field].(map[string]any); ok {
+			obj = inner
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", k, formatTableValue(obj[k]))
+	}
+	return w.Flush()
+}
+
+// printQueryList prints res as json, or the list under field as a table with columns.
+func printQueryList(cmd *cobra.Command, res proto.Message, field string, columns []string) error {
+	bz, m, err := toJsonMap(res)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if output, _ := cmd.Flags().GetString("output"); output == outputJson {
+		_, err := fmt.Fprintln(out, string(bz))
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+	items, _ := m[field].([]any)
+	for _, item := range items {
+		obj, _ := item.(map[string]any)
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = formatTableValue(obj[column])
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if pagination, ok := m["pagination"].(map[string]any); ok {
+		if nextKey, ok := pagination["next_key"].(string); ok && nextKey != "" {
+			fmt.Fprintf(out, "\nnext page: --page-key %s\n", nextKey)
+		}
+	}
+	return nil
+}
+
+func formatTableValue(v any) string {
+	var s string
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		s = value
+	case []any:
+		s = fmt.Sprintf("[%d items]", len(value))
+	default:
+		bz, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		s = string(bz)
+	}
+	if len(s) > maxTableValueLength {
+		return s[:maxTableValueLength] + "..."
+	}
+	return s
+}
+
+func init() {
+	queryCmd.PersistentFlags().StringP("output", "o", outputTable, "output format (table|json)")
+
+	queryPublishedDataCmd.Flags().String("status", "", "only list data with this status (e.g. challenging, verified), requesting pages until --limit matches are found")
+	queryPublishedDataCmd.Flags().Uint64("limit", 100, "number of published data per page")
+	queryPublishedDataCmd.Flags().String("page-key", "", "next page key returned by the previous page")
+
+	queryCmd.AddCommand(
+		queryParamsCmd,
+		queryPublishedDataCmd,
+		queryValidityProofCmd,
+		queryProofDeputyCmd,
+		queryZkpProofThresholdCmd,
+		queryInvalidityCmd,
+	)
+	rootCmd.AddCommand(queryCmd)
+}
@@ -3,6 +3,9 @@ package cmd
 import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/sunriselayer/sunrise-data/alert"
+	"github.com/sunriselayer/sunrise-data/api"
+	"github.com/sunriselayer/sunrise-data/balance"
 	"github.com/sunriselayer/sunrise-data/config"
 	"github.com/sunriselayer/sunrise-data/context"
 	"github.com/sunriselayer/sunrise-data/protocols"
@@ -30,6 +33,21 @@ var rollkitCmd = &cobra.Command{
 			return err
 		}
 
+		if err := alert.Start(*config); err != nil {
+			log.Error().Msgf("Failed to start alerts: %s", err)
+			return err
+		}
+
+		if _, err := balance.Start(balance.RolePublisher, context.Addr, config.Publish.PublishFees); err != nil {
+			log.Error().Msgf("Failed to start balance watcher: %s", err)
+			return err
+		}
+
+		if _, err := api.PublishProtocol(config.Rollkit.Protocol, config.Rollkit.ReplicationTargets); err != nil {
+			log.Error().Msgf("Invalid rollkit config: %s", err)
+			return err
+		}
+
 		rollkit.Serve()
 		return nil
 	},
@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"github.com/sunriselayer/sunrise-data/alert"
+	"github.com/sunriselayer/sunrise-data/balance"
 	"github.com/sunriselayer/sunrise-data/config"
 	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/leader"
 	"github.com/sunriselayer/sunrise-data/protocols"
 	"github.com/sunriselayer/sunrise-data/validator"
 )
@@ -33,6 +37,33 @@ var validatorCmd = &cobra.Command{
 			return err
 		}
 
+		if err := alert.Start(*config); err != nil {
+			log.Error().Msgf("Failed to start alerts: %s", err)
+			return err
+		}
+
+		pairs := config.ValidatorPairs()
+		for _, pair := range pairs {
+			deputyAddress, err := validator.DeputyAddress(pair.ProofDeputyAccount)
+			if err != nil {
+				log.Error().Msgf("Failed to get proof deputy: %s", err)
+				return err
+			}
+			role := balance.RoleDeputy
+			if len(pairs) > 1 {
+				role = fmt.Sprintf("%s %s", balance.RoleDeputy, pair.ValidatorAddress)
+			}
+			if _, err := balance.Start(role, deputyAddress, config.Validator.ProofFees); err != nil {
+				log.Error().Msgf("Failed to start balance watcher: %s", err)
+				return err
+			}
+		}
+
+		if err := leader.Start(*config); err != nil {
+			log.Error().Msgf("Failed to start leader election: %s", err)
+			return err
+		}
+
 		ok := validator.RunValidatorTask()
 		if !ok {
 			return errors.New("failed to start validator task")
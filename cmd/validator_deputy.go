@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/sunriselayer/sunrise-data/config"
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/validator"
+)
+
+var registerDeputyCmd = &cobra.Command{
+	Use:   "register-deputy",
+	Short: "Register proof_deputy_account as the proof deputy of the validator",
+	Long: `This command sends MsgRegisterProofDeputy signed by the validator operator key.
+The operator key is the key of validator_address in the keyring, or the key given by --from.
+If several validators are configured, choose one with --validator.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadOperatorContext(); err != nil {
+			return err
+		}
+		pair, err := validatorPair(cmd)
+		if err != nil {
+			return err
+		}
+		validatorAddress := pair.ValidatorAddress
+		from, _ := cmd.Flags().GetString("from")
+		operator, err := validator.OperatorAccount(validatorAddress, from)
+		if err != nil {
+			return fmt.Errorf("failed to get validator operator key: %w", err)
+		}
+		deputyAddress, err := validator.DeputyAddress(pair.ProofDeputyAccount)
+		if err != nil {
+			return err
+		}
+
+		txHash, err := validator.RegisterProofDeputy(operator, deputyAddress)
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("MsgRegisterProofDeputy TxHash: %s", txHash)
+
+		if err := validator.CheckDeputy(validatorAddress, deputyAddress); err != nil {
+			return err
+		}
+		log.Info().Msgf("%s is registered as the proof deputy of %s", deputyAddress, validatorAddress)
+		return nil
+	},
+}
+
+var unregisterDeputyCmd = &cobra.Command{
+	Use:   "unregister-deputy",
+	Short: "Unregister the proof deputy of the validator",
+	Long:  `This command sends MsgUnregisterProofDeputy signed by the validator operator key.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadOperatorContext(); err != nil {
+			return err
+		}
+		pair, err := validatorPair(cmd)
+		if err != nil {
+			return err
+		}
+		validatorAddress := pair.ValidatorAddress
+		from, _ := cmd.Flags().GetString("from")
+		operator, err := validator.OperatorAccount(validatorAddress, from)
+		if err != nil {
+			return fmt.Errorf("failed to get validator operator key: %w", err)
+		}
+
+		txHash, err := validator.UnregisterProofDeputy(operator)
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("MsgUnregisterProofDeputy TxHash: %s", txHash)
+
+		if deputy, err := validator.OnchainDeputy(validatorAddress); err == nil && deputy != "" {
+			return fmt.Errorf("%s is still registered as the proof deputy of %s", deputy, validatorAddress)
+		}
+		log.Info().Msgf("Proof deputy of %s is unregistered", validatorAddress)
+		return nil
+	},
+}
+
+var showDeputyCmd = &cobra.Command{
+	Use:   "show-deputy",
+	Short: "Show the on-chain proof deputy of the validators",
+	Long:  `This command shows the on-chain proof deputy of each configured validator, or of the validator given by --validator.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadOperatorContext(); err != nil {
+			return err
+		}
+		pairs := context.Config.ValidatorPairs()
+		if address, _ := cmd.Flags().GetString("validator"); address != "" {
+			pair, err := validatorPair(cmd)
+			if err != nil {
+				return err
+			}
+			pairs = []config.ValidatorPair{pair}
+		}
+
+		out := cmd.OutOrStdout()
+		for i, pair := range pairs {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			deputyAddress, err := validator.DeputyAddress(pair.ProofDeputyAccount)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "validator:         %s\n", pair.ValidatorAddress)
+			fmt.Fprintf(out, "configured deputy: %s\n", deputyAddress)
+			onchain, err := validator.OnchainDeputy(pair.ValidatorAddress)
+			if err != nil {
+				fmt.Fprintf(out, "on-chain deputy:   none (%s)\n", err)
+				continue
+			}
+			fmt.Fprintf(out, "on-chain deputy:   %s\n", onchain)
+			fmt.Fprintf(out, "match:             %t\n", onchain == deputyAddress)
+		}
+		return nil
+	},
+}
+
+// validatorPair returns the configured validator given by --validator,
+// which can be omitted when a single validator is configured.
+func validatorPair(cmd *cobra.Command) (config.ValidatorPair, error) {
+	pairs := context.Config.ValidatorPairs()
+	address, _ := cmd.Flags().GetString("validator")
+	if address == "" {
+		switch len(pairs) {
+		case 0:
+			return config.ValidatorPair{}, fmt.Errorf("validator_address is not configured")
+		case 1:
+			return pairs[0], nil
+		}
+		return config.ValidatorPair{}, fmt.Errorf("%d validators are configured, choose one with --validator", len(pairs))
+	}
+	for _, pair := range pairs {
+		if pair.ValidatorAddress == address {
+			return pair, nil
+		}
+	}
+	return config.ValidatorPair{}, fmt.Errorf("validator %s is not configured", address)
+}
+
+func loadOperatorContext() error {
+	config, err := config.LoadConfig()
+	if err != nil {
+		log.Error().Msgf("Failed to load config: %s", err)
+		return err
+	}
+
+	if err = context.GetOperatorContext(*config); err != nil {
+		log.Error().Msgf("Failed to connect to sunrised RPC: %s", err)
+		return err
+	}
+	return nil
+}
+
+func init() {
+	registerDeputyCmd.Flags().String("from", "", "key name of the validator operator (default: the key of validator_address)")
+	unregisterDeputyCmd.Flags().String("from", "", "key name of the validator operator (default: the key of validator_address)")
+	for _, cmd := range []*cobra.Command{registerDeputyCmd, unregisterDeputyCmd, showDeputyCmd} {
+		cmd.Flags().String("validator", "", "validator address of the [[validator.validators]] entry to use")
+	}
+
+	validatorCmd.AddCommand(registerDeputyCmd, unregisterDeputyCmd, showDeputyCmd)
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/sunriselayer/sunrise-data/config"
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/protocols"
+	"github.com/sunriselayer/sunrise-data/validator"
+)
+
+var validatorProveCmd = &cobra.Command{
+	Use:   "prove [metadata_uri]",
+	Short: "Prove a single published data",
+	Long: `This command proves a published data for the configured validators, or for the validator given by --validator,
+as the running validator does for a challenging data.
+With --dry-run, the shards are fetched and the proofs are generated, but nothing is broadcast and no deputy key is needed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		outputFile, _ := cmd.Flags().GetString("output")
+		validatorAddress, _ := cmd.Flags().GetString("validator")
+
+		config, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if dryRun {
+			err = context.GetQueryContext(*config)
+		} else {
+			err = context.GetProofContext(*config)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to connect to sunrised RPC: %w", err)
+		}
+		if err := protocols.CheckIpfsConnection(); err != nil {
+			return fmt.Errorf("failed to connect to IPFS: %w", err)
+		}
+
+		reports, err := validator.ProveOnce(args[0], validatorAddress, dryRun)
+		if err != nil {
+			return err
+		}
+
+		if outputFile != "" {
+			bz, err := json.MarshalIndent(struct {
+				MetadataUri string                  `json:"metadata_uri"`
+				DryRun      bool                    `json:"dry_run"`
+				Validators  []validator.ProofReport `json:"validators"`
+			}{args[0], dryRun, reports}, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(outputFile, bz, 0o644); err != nil {
+				return err
+			}
+			log.Info().Msgf("Proofs are written to %s", outputFile)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VALIDATOR\tREQUIRED_INDICES\tPROVED_INDICES\tTX_HASH\tERROR")
+		failed := 0
+		for _, report := range reports {
+			if report.Error != "" {
+				failed++
+			}
+			fmt.Fprintf(w, "%s\t%v\t%v\t%s\t%s\n", report.ValidatorAddress, report.RequiredIndices, report.Indices, report.TxHash, report.Error)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to prove %s for %d validators", args[0], failed)
+		}
+		return nil
+	},
+}
+
+var validatorCheckCmd = &cobra.Command{
+	Use:   "check [metadata_uri]",
+	Short: "Check the availability and hash of every shard of a published data",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output != outputTable && output != outputJson {
+			return fmt.Errorf("unsupported output %q, use %q or %q", output, outputTable, outputJson)
+		}
+
+		config, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if err := context.GetQueryContext(*config); err != nil {
+			return fmt.Errorf("failed to connect to sunrised RPC: %w", err)
+		}
+		if err := protocols.CheckIpfsConnection(); err != nil {
+			return fmt.Errorf("failed to connect to IPFS: %w", err)
+		}
+
+		check, err := validator.CheckData(args[0])
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if output == outputJson {
+			return json.NewEncoder(out).Encode(check)
+		}
+		fmt.Fprintf(out, "metadata_uri: %s\n", check.MetadataUri)
+		fmt.Fprintf(out, "status:       %s\n", check.Status)
+		fmt.Fprintf(out, "shards:       %d valid of %d, %d data shards, threshold %d\n", check.ValidShards, check.ShardCount, check.DataShardCount, check.Threshold)
+		fmt.Fprintf(out, "recoverable:  %t\n\n", check.Recoverable)
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "INDEX\tAVAILABLE\tSIZE\tVALID_HASH\tASSIGNED_TO\tERROR")
+		for _, shard := range check.Shards {
+			fmt.Fprintf(w, "%d\t%t\t%d\t%t\t%s\t%s\n",
+				shard.Index, shard.Available, shard.Size, shard.ValidHash,
+				strings.Join(shard.AssignedTo, ","), formatTableValue(shard.Error))
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	validatorProveCmd.Flags().Bool("dry-run", false, "generate the proofs without broadcasting them")
+	validatorProveCmd.Flags().String("output", "", "file to write the indices and proofs to as JSON")
+	validatorProveCmd.Flags().String("validator", "", "only prove for this validator address")
+	validatorCheckCmd.Flags().StringP("output", "o", outputTable, "output format (table|json)")
+
+	validatorCmd.AddCommand(validatorProveCmd, validatorCheckCmd)
+}
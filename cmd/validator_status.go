@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sunriselayer/sunrise-data/config"
+	"github.com/sunriselayer/sunrise-data/validator"
+	"github.com/sunriselayer/sunrise-data/validator/state"
+)
+
+var validatorStatusCmd = &cobra.Command{
+	Use:   "status [metadata_uri]",
+	Short: "Show the proof state kept by the validator",
+	Long: `This command reads the proof state of each metadata URI and validator from state_dir in config.toml.
+It can be run while the validator is running.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output != outputTable && output != outputJson {
+			return fmt.Errorf("unsupported output %q, use %q or %q", output, outputTable, outputJson)
+		}
+		store, err := openValidatorState()
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		records, err := listStateRecords(cmd, store)
+		if err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			uriRecords := []state.Record{}
+			for _, record := range records {
+				if record.MetadataUri == args[0] {
+					uriRecords = append(uriRecords, record)
+				}
+			}
+			if len(uriRecords) == 0 {
+				return fmt.Errorf("no state for %s", args[0])
+			}
+			if output == outputJson {
+				return json.NewEncoder(out).Encode(uriRecords)
+			}
+			for i, record := range uriRecords {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				if err := printStateRecord(cmd, record); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if filter, _ := cmd.Flags().GetString("state"); filter != "" {
+			filtered := []state.Record{}
+			for _, record := range records {
+				if string(record.State) == filter {
+					filtered = append(filtered, record)
+				}
+			}
+			records = filtered
+		}
+		lastHeight, err := store.LastHeight()
+		if err != nil {
+			return err
+		}
+
+		if output == outputJson {
+			return json.NewEncoder(out).Encode(struct {
+				LastProcessedHeight int64          `json:"last_processed_height"`
+				Records             []state.Record `json:"records"`
+			}{lastHeight, records})
+		}
+
+		fmt.Fprintf(out, "last processed height: %d\n\n", lastHeight)
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "METADATA_URI\tVALIDATOR\tSTATE\tFAILURES\tUPDATED_AT\tREASON")
+		for _, record := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n",
+				record.MetadataUri, record.ValidatorAddress, record.State, record.Failures,
+				record.UpdatedAt.Format(time.RFC3339), formatTableValue(record.Reason))
+		}
+		return w.Flush()
+	},
+}
+
+var validatorDeadLettersCmd = &cobra.Command{
+	Use:   "dead-letters",
+	Short: "List the proofs that failed retry_max_attempts times",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output != outputTable && output != outputJson {
+			return fmt.Errorf("unsupported output %q, use %q or %q", output, outputTable, outputJson)
+		}
+		store, err := openValidatorState()
+		if err != nil {
+			return err
+		}
+		records, err := listStateRecords(cmd, store)
+		if err != nil {
+			return err
+		}
+		deadLetters := []state.Record{}
+		for _, record := range records {
+			if record.State == state.StateDeadLetter {
+				deadLetters = append(deadLetters, record)
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		if output == outputJson {
+			return json.NewEncoder(out).Encode(deadLetters)
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "METADATA_URI\tVALIDATOR\tFAILURES\tFAILURE_KIND\tUPDATED_AT\tREASON")
+		for _, record := range deadLetters {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+				record.MetadataUri, record.ValidatorAddress, record.Failures, record.FailureKind,
+				record.UpdatedAt.Format(time.RFC3339), formatTableValue(record.Reason))
+		}
+		return w.Flush()
+	},
+}
+
+var validatorReplayCmd = &cobra.Command{
+	Use:   "replay [metadata_uri]...",
+	Short: "Prove dead letters or failed proofs again",
+	Long: `This command resets the state of the given metadata URIs, or of every dead letter with --all,
+for every validator or for the validator given by --validator.
+A running validator proves them again on its next proof_interval while they are challenging.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		if all == (len(args) > 0) {
+			return errors.New("give metadata URIs or --all")
+		}
+		store, err := openValidatorState()
+		if err != nil {
+			return err
+		}
+		records, err := listStateRecords(cmd, store)
+		if err != nil {
+			return err
+		}
+
+		uris := map[string]bool{}
+		for _, uri := range args {
+			uris[uri] = false
+		}
+		for _, record := range records {
+			if _, ok := uris[record.MetadataUri]; !all && !ok {
+				continue
+			}
+			if record.State != state.StateDeadLetter && (all || record.State != state.StateFailed) {
+				continue
+			}
+			if _, err := store.Replay(record.ValidatorAddress, record.MetadataUri); err != nil {
+				return err
+			}
+			uris[record.MetadataUri] = true
+			fmt.Fprintf(cmd.OutOrStdout(), "replaying %s of %s\n", record.MetadataUri, record.ValidatorAddress)
+		}
+		for uri, replayed := range uris {
+			if !replayed {
+				return fmt.Errorf("%s has no %s or %s state to replay", uri, state.StateDeadLetter, state.StateFailed)
+			}
+		}
+		return nil
+	},
+}
+
+func openValidatorState() (*state.Store, error) {
+	config, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return state.Open(validator.StateDir(*config))
+}
+
+// listStateRecords returns the records of the store, only of the validator given by --validator if any.
+func listStateRecords(cmd *cobra.Command, store *state.Store) ([]state.Record, error) {
+	records, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	validatorAddress, _ := cmd.Flags().GetString("validator")
+	if validatorAddress == "" {
+		return records, nil
+	}
+	filtered := []state.Record{}
+	for _, record := range records {
+		if record.ValidatorAddress == validatorAddress {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered, nil
+}
+
+func printStateRecord(cmd *cobra.Command, record state.Record) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "metadata_uri\t%s\n", record.MetadataUri)
+	fmt.Fprintf(w, "validator\t%s\n", record.ValidatorAddress)
+	fmt.Fprintf(w, "state\t%s\n", record.State)
+	fmt.Fprintf(w, "reason\t%s\n", record.Reason)
+	fmt.Fprintf(w, "failures\t%d\n", record.Failures)
+	fmt.Fprintf(w, "failure_kind\t%s\n", record.FailureKind)
+	if !record.NextAttemptAt.IsZero() {
+		fmt.Fprintf(w, "next_attempt_at\t%s\n", record.NextAttemptAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "indices\t%v\n", record.Indices)
+	fmt.Fprintf(w, "tx_hash\t%s\n", record.TxHash)
+	fmt.Fprintf(w, "created_at\t%s\n", record.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "updated_at\t%s\n", record.UpdatedAt.Format(time.RFC3339))
+	fmt.Fprintln(w, "\nhistory")
+	for _, transition := range record.History {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", transition.At.Format(time.RFC3339), transition.State, transition.Reason)
+	}
+
+	if audit := record.Audit; audit != nil {
+		fmt.Fprintln(w, "\naudit")
+		fmt.Fprintf(w, "checked_at\t%s\n", audit.CheckedAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "consistent\t%t\n", len(audit.Problems) == 0)
+		for _, problem := range audit.Problems {
+			fmt.Fprintf(w, "problem\t%s\n", problem)
+		}
+	}
+
+	if invalidity := record.Invalidity; invalidity != nil {
+		fmt.Fprintln(w, "\ninvalidity")
+		fmt.Fprintf(w, "indices\t%v\n", invalidity.Indices)
+		fmt.Fprintf(w, "tx_hash\t%s\n", invalidity.TxHash)
+		fmt.Fprintf(w, "error\t%s\n", invalidity.Error)
+		fmt.Fprintf(w, "decided_at\t%s\n", invalidity.DecidedAt.Format(time.RFC3339))
+		fmt.Fprintln(w, "\nINDEX\tSHARD_URI\tATTEMPTS\tERROR")
+		for _, e := range invalidity.Evidence {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", e.Index, e.ShardUri, e.Attempts, e.Error)
+		}
+	}
+	return w.Flush()
+}
+
+func init() {
+	validatorStatusCmd.Flags().StringP("output", "o", outputTable, "output format (table|json)")
+	validatorStatusCmd.Flags().String("state", "", "only list metadata URIs in this state (e.g. failed)")
+
+	validatorDeadLettersCmd.Flags().StringP("output", "o", outputTable, "output format (table|json)")
+	validatorReplayCmd.Flags().Bool("all", false, "replay every dead letter")
+
+	for _, cmd := range []*cobra.Command{validatorStatusCmd, validatorDeadLettersCmd, validatorReplayCmd} {
+		cmd.Flags().String("validator", "", "only the records of this validator address")
+	}
+
+	validatorCmd.AddCommand(validatorStatusCmd, validatorDeadLettersCmd, validatorReplayCmd)
+}
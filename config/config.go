@@ -15,28 +15,137 @@ type Config struct {
 		HomePath       string `toml:"home_path"`
 		KeyringBackend string `toml:"keyring_backend"`
 		SunrisedRPC    string `toml:"sunrised_rpc"`
+
+		KeyringPassphraseEnv     string `toml:"keyring_passphrase_env"`
+		KeyringPassphraseFile    string `toml:"keyring_passphrase_file"`
+		KeyringPassphraseCommand string `toml:"keyring_passphrase_command"`
 	}
 	Publish struct {
 		PublisherAccount string `toml:"publisher_account"`
 		PublishFees      string `toml:"publish_fees"`
 	}
+	Balance struct {
+		CheckInterval        int    `toml:"check_interval"`
+		WarnRemainingTxs     uint64 `toml:"warn_remaining_txs"`
+		RefusePublishWhenLow bool   `toml:"refuse_publish_when_low"`
+	}
+	Faucet struct {
+		Enabled   bool   `toml:"enabled"`
+		Url       string `toml:"url"`
+		Denom     string `toml:"denom"`
+		MinAmount uint64 `toml:"min_amount"`
+
+		Port    int    `toml:"port"`
+		Account string `toml:"account"`
+		Amount  string `toml:"amount"`
+		Fees    string `toml:"fees"`
+	}
 	Validator struct {
-		ProofDeputyAccount string `toml:"proof_deputy_account"`
-		ValidatorAddress   string `toml:"validator_address"`
-		ProofFees          string `toml:"proof_fees"`
-		ProofInterval      int    `toml:"proof_interval"`
+		ProofDeputyAccount      string `toml:"proof_deputy_account"`
+		ValidatorAddress        string `toml:"validator_address"`
+		ProofFees               string `toml:"proof_fees"`
+		ProofInterval           int    `toml:"proof_interval"`
+		CatchUpBlocks           int64  `toml:"catch_up_blocks"`
+		ReconcileInterval       int    `toml:"reconcile_interval"`
+		SweepPageSize           uint64 `toml:"sweep_page_size"`
+		StateDir                string `toml:"state_dir"`
+		StateRetention          int    `toml:"state_retention"`
+		DataWorkers             int    `toml:"data_workers"`
+		FetchWorkers            int    `toml:"fetch_workers"`
+		ProofWorkers            int    `toml:"proof_workers"`
+		MaxShardMemoryMiB       int64  `toml:"max_shard_memory_mib"`
+		FullAudit               bool   `toml:"full_audit"`
+		ReconstructionAudit     string `toml:"reconstruction_audit"`
+		PrefetchShards          int    `toml:"prefetch_shards"`
+		InvalidityRetries       int    `toml:"invalidity_retries"`
+		InvalidityRetryInterval int    `toml:"invalidity_retry_interval"`
+		NearMissMargin          int    `toml:"near_miss_margin"`
+		RetryMaxAttempts        int    `toml:"retry_max_attempts"`
+		RetryMaxBackoff         int    `toml:"retry_max_backoff"`
+		RetryBackoffRetrieval   int    `toml:"retry_backoff_retrieval"`
+		RetryBackoffQuery       int    `toml:"retry_backoff_query"`
+		RetryBackoffProof       int    `toml:"retry_backoff_proof"`
+		RetryBackoffBroadcast   int    `toml:"retry_backoff_broadcast"`
+		BatchWindow             int    `toml:"batch_window"`
+		BatchMaxMsgs            int    `toml:"batch_max_msgs"`
+		BatchMaxBytes           int    `toml:"batch_max_bytes"`
+		BatchMaxGas             uint64 `toml:"batch_max_gas"`
+		StatusPort              int    `toml:"status_port"`
+
+		Validators []ValidatorPair `toml:"validators"`
+	}
+	Leader struct {
+		Backend      string `toml:"backend"`
+		Id           string `toml:"id"`
+		LeaseTimeout int    `toml:"lease_timeout"`
+		LockFile     string `toml:"lock_file"`
+		Url          string `toml:"url"`
+		LeaseName    string `toml:"lease_name"`
+
+		Port int `toml:"port"`
+	}
+	Alert struct {
+		Source                  string         `toml:"source"`
+		RateLimit               int            `toml:"rate_limit"`
+		RateLimits              map[string]int `toml:"rate_limits"`
+		RateInterval            int            `toml:"rate_interval"`
+		MaxRetries              int            `toml:"max_retries"`
+		RetryInterval           int            `toml:"retry_interval"`
+		Timeout                 int            `toml:"timeout"`
+		CheckInterval           int            `toml:"check_interval"`
+		OutageThreshold         int            `toml:"outage_threshold"`
+		PublishFailureThreshold int            `toml:"publish_failure_threshold"`
+
+		Webhooks []AlertWebhook `toml:"webhooks"`
 	}
 	Rollkit struct {
-		Port             int `toml:"port"`
-		DataShardCount   int `toml:"data_shard_count"`
-		ParityShardCount int `toml:"parity_shard_count"`
+		Port               int      `toml:"port"`
+		DataShardCount     int      `toml:"data_shard_count"`
+		ParityShardCount   int      `toml:"parity_shard_count"`
+		Protocol           string   `toml:"protocol"`
+		ReplicationTargets []string `toml:"replication_targets"`
+		ReplicationTimeout int      `toml:"replication_timeout"`
 	}
 	Optimism struct {
-		ListenAddress    string `toml:"listen_address"`
-		Port             int    `toml:"port"`
-		DataShardCount   int    `toml:"data_shard_count"`
-		ParityShardCount int    `toml:"parity_shard_count"`
+		ListenAddress      string   `toml:"listen_address"`
+		Port               int      `toml:"port"`
+		DataShardCount     int      `toml:"data_shard_count"`
+		ParityShardCount   int      `toml:"parity_shard_count"`
+		Protocol           string   `toml:"protocol"`
+		ReplicationTargets []string `toml:"replication_targets"`
+		ReplicationTimeout int      `toml:"replication_timeout"`
+		KeccakIndexDir     string   `toml:"keccak_index_dir"`
+	}
+}
+
+// AlertWebhook is an HTTP endpoint receiving alert events.
+type AlertWebhook struct {
+	Url string `toml:"url"`
+	// Secret is the key of the HMAC signature of the events, which are not signed if it is empty.
+	Secret string `toml:"secret"`
+	// Events are the event types sent to the webhook, every type if empty.
+	Events []string `toml:"events"`
+}
+
+// ValidatorPair is a validator proven by this process and the deputy account submitting its proofs.
+type ValidatorPair struct {
+	ValidatorAddress   string `toml:"validator_address"`
+	ProofDeputyAccount string `toml:"proof_deputy_account"`
+}
+
+// ValidatorPairs returns the [[validator.validators]] entries, or the single
+// validator_address and proof_deputy_account if there is none.
+func (c Config) ValidatorPairs() []ValidatorPair {
+	if len(c.Validator.Validators) > 0 {
+		return c.Validator.Validators
+	}
+	if c.Validator.ValidatorAddress == "" {
+		return nil
 	}
+	return []ValidatorPair{{
+		ValidatorAddress:   c.Validator.ValidatorAddress,
+		ProofDeputyAccount: c.Validator.ProofDeputyAccount,
+	}}
 }
 
 func LoadConfig() (*Config, error) {
@@ -23,56 +23,53 @@ var (
 )
 
 func GetPublishContext(conf config.Config) error {
-	Config = conf
-	Ctx = context.Background()
-
-	if conf.Chain.SunrisedRPC == "" {
-		return fmt.Errorf("sunrised_rpc is not configured")
+	if err := connect(conf, conf.Publish.PublishFees); err != nil {
+		return err
 	}
-	log.Info().Msgf("sunrised_rpc: %s", conf.Chain.SunrisedRPC)
 
-	sdkConfig := sdk.GetConfig()
-	sdkConfig.SetBech32PrefixForAccount(conf.Chain.AddressPrefix, conf.Chain.AddressPrefix+"pub")
-	sdkConfig.SetBech32PrefixForValidator(conf.Chain.AddressPrefix+"valoper", conf.Chain.AddressPrefix+"valoperpub")
-	sdkConfig.SetBech32PrefixForConsensusNode(conf.Chain.AddressPrefix+"valcons", conf.Chain.AddressPrefix+"valconspub")
-	sdkConfig.Seal()
+	// Get publisher account from the keyring
+	return loadAccount(conf.Publish.PublisherAccount, "publisher")
+}
 
-	var err error
-	NodeClient, err = cosmosclient.New(
-		Ctx,
-		cosmosclient.WithNodeAddress(conf.Chain.SunrisedRPC),
-		cosmosclient.WithAddressPrefix(conf.Chain.AddressPrefix),
-		cosmosclient.WithKeyringBackend(cosmosaccount.KeyringBackend(conf.Chain.KeyringBackend)),
-		cosmosclient.WithHome(conf.Chain.HomePath),
-		cosmosclient.WithFees(conf.Publish.PublishFees),
-		cosmosclient.WithGasAdjustment(1.5),
-		cosmosclient.WithGas(cosmosclient.GasAuto),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create cosmos client: %w", err)
+func GetProofContext(conf config.Config) error {
+	if err := connect(conf, conf.Validator.ProofFees); err != nil {
+		return err
 	}
 
-	_, err = NodeClient.Status(Ctx)
-	if err != nil {
-		return fmt.Errorf("failed to connect to RPC at %s: %w", conf.Chain.SunrisedRPC, err)
+	// Get the deputy account of the first validator from the keyring,
+	// the deputies of the other validators are loaded by the validator task
+	pairs := conf.ValidatorPairs()
+	if len(pairs) == 0 {
+		return fmt.Errorf("validator_address is not configured")
 	}
+	return loadAccount(pairs[0].ProofDeputyAccount, "deputy")
+}
 
-	QueryClient = datypes.NewQueryClient(NodeClient.Context())
+// GetOperatorContext connects to sunrised with the proof fees but loads no account,
+// so that the validator operator key can be used for deputy registration.
+func GetOperatorContext(conf config.Config) error {
+	return connect(conf, conf.Validator.ProofFees)
+}
 
-	// Get publisher account from the keyring
-	Account, err = NodeClient.Account(conf.Publish.PublisherAccount)
-	if err != nil {
+// GetFaucetContext connects to sunrised with the account of the stand-in faucet.
+func GetFaucetContext(conf config.Config) error {
+	// the faucet itself must never request funds from a faucet
+	conf.Faucet.Enabled = false
+	if err := connect(conf, conf.Faucet.Fees); err != nil {
 		return err
 	}
-	Addr, err = Account.Address(conf.Chain.AddressPrefix)
-	if err != nil {
-		return err
-	}
-	log.Info().Msgf("publisher address: %v", Addr)
-	return nil
+
+	return loadAccount(conf.Faucet.Account, "faucet")
 }
 
-func GetProofContext(conf config.Config) error {
+// GetQueryContext connects to sunrised for queries only, no key is loaded from the keyring.
+func GetQueryContext(conf config.Config) error {
+	conf.Chain.KeyringBackend = string(cosmosaccount.KeyringMemory)
+	conf.Faucet.Enabled = false
+	return connect(conf, "")
+}
+
+func connect(conf config.Config, fees string) error {
 	Config = conf
 	Ctx = context.Background()
 
@@ -87,17 +84,27 @@ func GetProofContext(conf config.Config) error {
 	sdkConfig.SetBech32PrefixForConsensusNode(conf.Chain.AddressPrefix+"valcons", conf.Chain.AddressPrefix+"valconspub")
 	sdkConfig.Seal()
 
-	var err error
-	NodeClient, err = cosmosclient.New(
-		Ctx,
+	passphrase, err := keyringPassphrase(conf)
+	if err != nil {
+		return err
+	}
+
+	options := []cosmosclient.Option{
 		cosmosclient.WithNodeAddress(conf.Chain.SunrisedRPC),
 		cosmosclient.WithAddressPrefix(conf.Chain.AddressPrefix),
 		cosmosclient.WithKeyringBackend(cosmosaccount.KeyringBackend(conf.Chain.KeyringBackend)),
+		cosmosclient.WithKeyringPassphrase(passphrase),
 		cosmosclient.WithHome(conf.Chain.HomePath),
-		cosmosclient.WithFees(conf.Validator.ProofFees),
+		cosmosclient.WithFees(fees),
 		cosmosclient.WithGasAdjustment(1.5),
 		cosmosclient.WithGas(cosmosclient.GasAuto),
-	)
+	}
+	if conf.Faucet.Enabled {
+		log.Warn().Msgf("faucet is enabled, funds are requested from %s when an account runs low", conf.Faucet.Url)
+		options = append(options, cosmosclient.WithUseFaucet(conf.Faucet.Url, conf.Faucet.Denom, conf.Faucet.MinAmount))
+	}
+
+	NodeClient, err = cosmosclient.New(Ctx, options...)
 	if err != nil {
 		return fmt.Errorf("failed to create cosmos client: %w", err)
 	}
@@ -108,16 +115,19 @@ func GetProofContext(conf config.Config) error {
 	}
 
 	QueryClient = datypes.NewQueryClient(NodeClient.Context())
+	return nil
+}
 
-	// Get deputy account from the keyring
-	Account, err = NodeClient.Account(conf.Validator.ProofDeputyAccount)
+func loadAccount(nameOrAddress string, role string) error {
+	var err error
+	Account, err = NodeClient.Account(nameOrAddress)
 	if err != nil {
 		return err
 	}
-	Addr, err = Account.Address(conf.Chain.AddressPrefix)
+	Addr, err = Account.Address(Config.Chain.AddressPrefix)
 	if err != nil {
 		return err
 	}
-	log.Info().Msgf("deputy address: %v", Addr)
+	log.Info().Msgf("%s address: %v", role, Addr)
 	return nil
 }
@@ -0,0 +1,37 @@
+package context
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sunriselayer/sunrise-data/config"
+	"github.com/sunriselayer/sunrise-data/cosmosclient/cosmosaccount"
+)
+
+// keyringPassphrase resolves the passphrase of the file and os keyring backends
+// from the sources in the chain config, so that startup never waits on a prompt.
+func keyringPassphrase(conf config.Config) (string, error) {
+	backend := cosmosaccount.KeyringBackend(conf.Chain.KeyringBackend)
+	if backend != cosmosaccount.KeyringFile && backend != cosmosaccount.KeyringOS {
+		return "", nil
+	}
+
+	sources := cosmosaccount.PassphraseSources{
+		Env:     conf.Chain.KeyringPassphraseEnv,
+		File:    conf.Chain.KeyringPassphraseFile,
+		Command: conf.Chain.KeyringPassphraseCommand,
+	}
+	if sources.IsEmpty() {
+		if backend == cosmosaccount.KeyringFile {
+			return "", fmt.Errorf("keyring_backend %q requires keyring_passphrase_env, keyring_passphrase_file or keyring_passphrase_command", backend)
+		}
+		// the os backend may not need a passphrase at all
+		return "", nil
+	}
+
+	passphrase, err := sources.Resolve(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to get keyring passphrase: %w", err)
+	}
+	return passphrase, nil
+}
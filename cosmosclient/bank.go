@@ -0,0 +1,43 @@
+package cosmosclient
+
+import (
+	"context"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+
+	"github.com/sunriselayer/sunrise-data/cosmosclient/cosmosaccount"
+	"github.com/sunriselayer/sunrise-data/cosmosclient/errors"
+)
+
+// BankBalances returns the account balances for the given address.
+func (c Client) BankBalances(ctx context.Context, address string, pagination *query.PageRequest) (sdktypes.Coins, error) {
+	req := &banktypes.QueryAllBalancesRequest{
+		Address:    address,
+		Pagination: pagination,
+	}
+
+	resp, err := c.bankQueryClient.AllBalances(ctx, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query balances of %s", address)
+	}
+
+	return resp.Balances, nil
+}
+
+// BankSendTx creates a tx that sends amount from the given account to toAddress.
+func (c Client) BankSendTx(ctx context.Context, fromAccount cosmosaccount.Account, toAddress string, amount sdktypes.Coins) (TxService, error) {
+	fromAddress, err := fromAccount.Address(c.bech32Prefix)
+	if err != nil {
+		return TxService{}, err
+	}
+
+	msg := &banktypes.MsgSend{
+		FromAddress: fromAddress,
+		ToAddress:   toAddress,
+		Amount:      amount,
+	}
+
+	return c.CreateTx(ctx, fromAccount, msg)
+}
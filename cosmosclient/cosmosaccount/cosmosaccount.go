@@ -53,6 +53,10 @@ const (
 	// stored in your operating system's secured keyring.
 	KeyringOS KeyringBackend = "os"
 
+	// KeyringFile is the file keyring backend. With this backend, your keys will be
+	// stored encrypted under your app's data dir and unlocked with a passphrase.
+	KeyringFile KeyringBackend = "file"
+
 	// KeyringMemory is in memory keyring backend, your keys will be stored in application memory.
 	KeyringMemory KeyringBackend = "memory"
 )
@@ -62,6 +66,7 @@ type Registry struct {
 	homePath           string
 	keyringServiceName string
 	keyringBackend     KeyringBackend
+	passphrase         string
 	addressCodec       addresscodec.Codec
 	coinType           uint32
 
@@ -89,6 +94,14 @@ func WithKeyringBackend(backend KeyringBackend) Option {
 	}
 }
 
+// WithPassphrase sets the passphrase used to unlock the file and OS keyring backends
+// instead of prompting for it on stdin.
+func WithPassphrase(passphrase string) Option {
+	return func(c *Registry) {
+		c.passphrase = passphrase
+	}
+}
+
 func WithBech32Prefix(prefix string) Option {
 	return func(c *Registry) {
 		c.addressCodec = address.NewBech32Codec(prefix)
@@ -120,7 +133,11 @@ func New(options ...Option) (Registry, error) {
 	interfaceRegistry := types.NewInterfaceRegistry()
 	cryptocodec.RegisterInterfaces(interfaceRegistry)
 	cdc := codec.NewProtoCodec(interfaceRegistry)
-	r.Keyring, err = keyring.New(r.keyringServiceName, string(r.keyringBackend), r.homePath, inBuf, cdc)
+	if r.passphrase != "" && (r.keyringBackend == KeyringFile || r.keyringBackend == KeyringOS) {
+		r.Keyring, err = newKeyringWithPassphrase(r.keyringServiceName, r.keyringBackend, r.homePath, r.passphrase, cdc)
+	} else {
+		r.Keyring, err = keyring.New(r.keyringServiceName, string(r.keyringBackend), r.homePath, inBuf, cdc)
+	}
 	if err != nil {
 		return Registry{}, err
 	}
@@ -0,0 +1,177 @@
+package cosmosaccount
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	dkeyring "github.com/99designs/keyring"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+
+	"github.com/sunriselayer/sunrise-data/cosmosclient/errors"
+)
+
+// PassphraseCommandTimeout is the maximum duration a passphrase command may run.
+var PassphraseCommandTimeout = 30 * time.Second
+
+// ErrNoPassphrase is returned when none of the configured passphrase sources yields a passphrase.
+var ErrNoPassphrase = errors.New("no keyring passphrase source is available")
+
+// PassphraseSources describes where a keyring passphrase can be read from
+// without prompting. Sources are tried in the order Env, File, Command.
+type PassphraseSources struct {
+	// Env is the name of an environment variable holding the passphrase.
+	Env string
+
+	// File is the path of a file holding the passphrase. The file must not be
+	// readable or writable by group or others.
+	File string
+
+	// Command is a shell command whose standard output is the passphrase,
+	// e.g. a secrets-provider CLI.
+	Command string
+}
+
+// IsEmpty tells if no passphrase source is configured.
+func (s PassphraseSources) IsEmpty() bool {
+	return s.Env == "" && s.File == "" && s.Command == ""
+}
+
+// Resolve returns the passphrase from the first source that yields a non-empty value.
+// The returned error lists why every configured source failed.
+func (s PassphraseSources) Resolve(ctx context.Context) (string, error) {
+	var errs []error
+
+	if s.Env != "" {
+		if pass := os.Getenv(s.Env); pass != "" {
+			return pass, nil
+		}
+		errs = append(errs, errors.Errorf("environment variable %s is not set", s.Env))
+	}
+
+	if s.File != "" {
+		pass, err := readPassphraseFile(s.File)
+		if err == nil {
+			return pass, nil
+		}
+		errs = append(errs, err)
+	}
+
+	if s.Command != "" {
+		pass, err := runPassphraseCommand(ctx, s.Command)
+		if err == nil {
+			return pass, nil
+		}
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return "", errors.Wrap(ErrNoPassphrase, "configure an environment variable, a file or a command")
+	}
+	return "", errors.Join(ErrNoPassphrase, errors.Join(errs...))
+}
+
+func readPassphraseFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "passphrase file %s", path)
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return "", errors.Errorf("passphrase file %s has permissions %04o, it must not be accessible by group or others", path, info.Mode().Perm())
+	}
+
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "passphrase file %s", path)
+	}
+	pass := strings.TrimRight(string(bz), "\r\n")
+	if pass == "" {
+		return "", errors.Errorf("passphrase file %s is empty", path)
+	}
+	return pass, nil
+}
+
+func runPassphraseCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, PassphraseCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(err, "passphrase command failed")
+	}
+	pass := strings.TrimRight(string(out), "\r\n")
+	if pass == "" {
+		return "", errors.New("passphrase command returned an empty output")
+	}
+	return pass, nil
+}
+
+// newKeyringWithPassphrase opens a file or OS keyring that is unlocked with passphrase
+// instead of prompting on stdin. The directory layout matches the Cosmos SDK keyring so
+// that keys created with the chain binary can be used.
+func newKeyringWithPassphrase(serviceName string, backend KeyringBackend, homePath, passphrase string, cdc codec.Codec) (keyring.Keyring, error) {
+	config := dkeyring.Config{
+		ServiceName: serviceName,
+	}
+
+	switch backend {
+	case KeyringFile:
+		config.AllowedBackends = []dkeyring.BackendType{dkeyring.FileBackend}
+		config.FileDir = filepath.Join(homePath, "keyring-file")
+	case KeyringOS:
+		config.FileDir = homePath
+		config.KeychainTrustApplication = true
+	default:
+		return nil, fmt.Errorf("keyring backend %q does not use a passphrase", backend)
+	}
+	config.FilePasswordFunc = fixedPassphrase(config.FileDir, passphrase)
+
+	db, err := dkeyring.Open(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// The in-memory constructor only wraps the given database, keys are still
+	// read from and written to the backend opened above.
+	return keyring.NewInMemoryWithKeyring(db, cdc), nil
+}
+
+// fixedPassphrase returns a password func that checks passphrase against the keyhash
+// stored in dir, or stores a new keyhash when the keyring is created.
+func fixedPassphrase(dir, passphrase string) func(string) (string, error) {
+	return func(string) (string, error) {
+		keyhashFilePath := filepath.Join(dir, "keyhash")
+
+		keyhash, err := os.ReadFile(keyhashFilePath)
+		switch {
+		case err == nil:
+			if err := bcrypt.CompareHashAndPassword(keyhash, []byte(passphrase)); err != nil {
+				return "", errors.Errorf("incorrect keyring passphrase for %s", dir)
+			}
+			return passphrase, nil
+		case os.IsNotExist(err):
+			passwordHash, err := bcrypt.GenerateFromPassword([]byte(passphrase), 2)
+			if err != nil {
+				return "", err
+			}
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				return "", err
+			}
+			if err := os.WriteFile(keyhashFilePath, passwordHash, 0o600); err != nil {
+				return "", err
+			}
+			return passphrase, nil
+		default:
+			return "", errors.Wrapf(err, "failed to read %s", keyhashFilePath)
+		}
+	}
+}
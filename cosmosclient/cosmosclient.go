@@ -116,6 +116,7 @@ type Client struct {
 	homePath           string
 	keyringServiceName string
 	keyringBackend     cosmosaccount.KeyringBackend
+	keyringPassphrase  string
 	keyringDir         string
 
 	gas           string
@@ -154,6 +155,14 @@ func WithKeyringBackend(backend cosmosaccount.KeyringBackend) Option {
 	}
 }
 
+// WithKeyringPassphrase sets the passphrase that unlocks the file and OS keyring backends.
+// When it is not provided, the keyring prompts for the passphrase on stdin.
+func WithKeyringPassphrase(passphrase string) Option {
+	return func(c *Client) {
+		c.keyringPassphrase = passphrase
+	}
+}
+
 // WithKeyringDir sets the directory of the keyring. By default, it uses cosmosaccount.KeyringHome.
 func WithKeyringDir(keyringDir string) Option {
 	return func(c *Client) {
@@ -264,6 +273,10 @@ func New(ctx context.Context, options ...Option) (Client, error) {
 		bech32Prefix:   "sunrise",
 		out:            io.Discard,
 		gas:            strconv.Itoa(defaultGasLimit),
+
+		faucetAddress:   defaultFaucetAddress,
+		faucetDenom:     defaultFaucetDenom,
+		faucetMinAmount: defaultFaucetMinAmount,
 	}
 
 	var err error
@@ -272,6 +285,12 @@ func New(ctx context.Context, options ...Option) (Client, error) {
 		apply(&c)
 	}
 
+	if c.useFaucet {
+		if u, err := url.Parse(c.faucetAddress); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Client{}, errors.Errorf("invalid faucet address %q, expected an http or https URL", c.faucetAddress)
+		}
+	}
+
 	if c.RPC == nil {
 		if c.RPC, err = rpchttp.New(c.nodeAddress, "/websocket"); err != nil {
 			return Client{}, err
@@ -305,6 +324,7 @@ func New(ctx context.Context, options ...Option) (Client, error) {
 	c.AccountRegistry, err = cosmosaccount.New(
 		cosmosaccount.WithKeyringServiceName(c.keyringServiceName),
 		cosmosaccount.WithKeyringBackend(c.keyringBackend),
+		cosmosaccount.WithPassphrase(c.keyringPassphrase),
 		cosmosaccount.WithHome(c.keyringDir),
 		cosmosaccount.WithBech32Prefix(c.bech32Prefix),
 	)
@@ -522,6 +542,16 @@ func (c Client) BroadcastTx(ctx context.Context, account cosmosaccount.Account,
 func (c Client) CreateTxWithOptions(ctx context.Context, account cosmosaccount.Account, options TxOptions, msgs ...sdktypes.Msg) (TxService, error) {
 	// defer c.lockBech32Prefix()()
 
+	if c.useFaucet && !c.generateOnly {
+		addr, err := account.Address(c.bech32Prefix)
+		if err != nil {
+			return TxService{}, errors.WithStack(err)
+		}
+		if err := c.makeSureAccountHasTokens(ctx, addr); err != nil {
+			return TxService{}, err
+		}
+	}
+
 	sdkaddr, err := account.Record.GetAddress()
 	if err != nil {
 		return TxService{}, errors.WithStack(err)
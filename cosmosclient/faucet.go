@@ -0,0 +1,138 @@
+package cosmosclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"cosmossdk.io/math"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+
+	"github.com/sunriselayer/sunrise-data/cosmosclient/errors"
+)
+
+// FaucetTransferRequest is the request body sent to the faucet.
+type FaucetTransferRequest struct {
+	// AccountAddress to transfer coins to.
+	AccountAddress string `json:"address"`
+
+	// Coins that are requested.
+	// The faucet sends its default amount when this is empty.
+	Coins []string `json:"coins"`
+}
+
+// FaucetTransferResponse is the response of the faucet.
+type FaucetTransferResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// WithUseFaucet sets the faucet address, denom and minAmount for when the client needs
+// to get funds. Funds are requested before a tx is created if the balance of denom is
+// lower than minAmount, and again when a tx is rejected for insufficient funds.
+func WithUseFaucet(faucetAddress, denom string, minAmount uint64) Option {
+	return func(c *Client) {
+		c.useFaucet = true
+		if faucetAddress != "" {
+			c.faucetAddress = faucetAddress
+		}
+		if denom != "" {
+			c.faucetDenom = denom
+		}
+		if minAmount != 0 {
+			c.faucetMinAmount = minAmount
+		}
+	}
+}
+
+// makeSureAccountHasTokens requests funds from the faucet when the account does not
+// have the faucet's minimum amount, and waits until the transfer is committed. A transfer
+// may not reach the minimum amount, which is then requested again before the next tx.
+func (c Client) makeSureAccountHasTokens(ctx context.Context, address string) error {
+	if err := c.checkAccountBalance(ctx, address); err == nil {
+		return nil
+	}
+	return c.requestFaucetFunds(ctx, address)
+}
+
+func (c Client) requestFaucetFunds(ctx context.Context, address string) error {
+	before, err := c.faucetDenomBalance(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(FaucetTransferRequest{AccountAddress: address})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.faucetAddress, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(errCannotRetrieveFundsFromFaucet, err.Error())
+	}
+	defer resp.Body.Close()
+
+	var faucetResp FaucetTransferResponse
+	if err := json.NewDecoder(resp.Body).Decode(&faucetResp); err != nil {
+		return errors.Wrapf(errCannotRetrieveFundsFromFaucet, "status %d: %s", resp.StatusCode, err)
+	}
+	if faucetResp.Error != "" {
+		return errors.Wrap(errCannotRetrieveFundsFromFaucet, faucetResp.Error)
+	}
+
+	// wait until the faucet transfer passes, which is when the balance increases.
+	ctx, cancel := context.WithTimeout(ctx, FaucetTransferEnsureDuration)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if balance, err := c.faucetDenomBalance(ctx, address); err == nil && balance.GT(before) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(errCannotRetrieveFundsFromFaucet, "timeout exceeded waiting for the faucet transfer")
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c Client) checkAccountBalance(ctx context.Context, address string) error {
+	balance, err := c.faucetDenomBalance(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	if balance.GTE(math.NewIntFromUint64(c.faucetMinAmount)) {
+		return nil
+	}
+
+	return errors.Errorf("account has not enough %q balance, min. required amount: %d", c.faucetDenom, c.faucetMinAmount)
+}
+
+// faucetDenomBalance returns the balance of the faucet denom of address.
+func (c Client) faucetDenomBalance(ctx context.Context, address string) (math.Int, error) {
+	balances, err := c.BankBalances(ctx, address, nil)
+	if err != nil {
+		return math.Int{}, err
+	}
+	return balances.AmountOf(c.faucetDenom), nil
+}
+
+// isInsufficientFunds tells if a broadcast failed because the account cannot pay the tx.
+func isInsufficientFunds(resp *sdktypes.TxResponse, err error) bool {
+	if resp != nil && resp.Codespace == sdkerrors.RootCodespace && resp.Code == sdkerrors.ErrInsufficientFunds.ABCICode() {
+		return true
+	}
+	return err != nil && strings.Contains(err.Error(), "insufficient funds")
+}
@@ -53,6 +53,13 @@ func (s TxService) Broadcast(ctx context.Context) (Response, error) {
 	}
 
 	resp, err := s.clientContext.BroadcastTx(txBytes)
+	if s.client.useFaucet && !s.client.generateOnly && isInsufficientFunds(resp, err) {
+		// the tx was rejected by CheckTx, so the same signed bytes can be sent again.
+		if err := s.client.requestFaucetFunds(ctx, s.clientContext.FromAddress.String()); err != nil {
+			return Response{}, err
+		}
+		resp, err = s.clientContext.BroadcastTx(txBytes)
+	}
 	if err := handleBroadcastResult(resp, err); err != nil {
 		return Response{}, err
 	}
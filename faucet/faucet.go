@@ -0,0 +1,107 @@
+package faucet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/cosmosclient"
+)
+
+// sendMu serializes transfers so that the faucet account sequence stays consistent.
+var sendMu sync.Mutex
+
+// Serve runs a stand-in faucet for local and test networks.
+// It accepts the same requests as the faucet that cosmosclient calls with WithUseFaucet.
+func Serve() error {
+	amount, err := sdk.ParseCoinsNormalized(context.Config.Faucet.Amount)
+	if err != nil {
+		return fmt.Errorf("failed to parse faucet amount %q: %w", context.Config.Faucet.Amount, err)
+	}
+	if amount.IsZero() {
+		return fmt.Errorf("faucet amount is not configured")
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		Transfer(w, r, amount)
+	}).Methods("POST")
+
+	log.Info().Msgf("Running faucet on localhost: %d, account: %s, amount: %s", context.Config.Faucet.Port, context.Addr, amount)
+	return http.ListenAndServe(fmt.Sprintf(":%d", context.Config.Faucet.Port), r)
+}
+
+func Transfer(w http.ResponseWriter, r *http.Request, maxAmount sdk.Coins) {
+	var req cosmosclient.FaucetTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := sdk.AccAddressFromBech32(req.AccountAddress); err != nil {
+		writeResponse(w, http.StatusBadRequest, fmt.Errorf("invalid address %q: %w", req.AccountAddress, err))
+		return
+	}
+
+	coins, err := requestedCoins(req.Coins, maxAmount)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sendMu.Lock()
+	defer sendMu.Unlock()
+
+	txService, err := context.NodeClient.BankSendTx(r.Context(), context.Account, req.AccountAddress, coins)
+	if err != nil {
+		log.Error().Msgf("Failed to create faucet transfer to %s: %s", req.AccountAddress, err)
+		writeResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	txResp, err := txService.Broadcast(r.Context())
+	if err != nil {
+		log.Error().Msgf("Failed to broadcast faucet transfer to %s: %s", req.AccountAddress, err)
+		writeResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Info().Msgf("Sent %s to %s, TxHash: %s", coins, req.AccountAddress, txResp.TxHash)
+	writeResponse(w, http.StatusOK, nil)
+}
+
+// requestedCoins returns the coins to send. An empty request gets maxAmount,
+// and a request may not ask for more than maxAmount.
+func requestedCoins(requested []string, maxAmount sdk.Coins) (sdk.Coins, error) {
+	if len(requested) == 0 {
+		return maxAmount, nil
+	}
+
+	coins := sdk.NewCoins()
+	for _, c := range requested {
+		coin, err := sdk.ParseCoinNormalized(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coin %q: %w", c, err)
+		}
+		coins = coins.Add(coin)
+	}
+	if !coins.IsAllLTE(maxAmount) {
+		return nil, fmt.Errorf("requested %s is more than the faucet amount %s", coins, maxAmount)
+	}
+	return coins, nil
+}
+
+func writeResponse(w http.ResponseWriter, status int, err error) {
+	res := cosmosclient.FaucetTransferResponse{}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// FileLease is not supported on this platform, use the http backend instead.
+type FileLease struct{}
+
+func NewFileLease(path string) *FileLease {
+	return &FileLease{}
+}
+
+func (l *FileLease) Acquire(_ context.Context, _ string, _ time.Duration) (bool, error) {
+	return false, errors.New("the file leader backend is not supported on this platform")
+}
@@ -0,0 +1,53 @@
+//go:build unix
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileLease is a lease held with an exclusive lock on a file, for instances on one host.
+// The lock is released by the OS when the process holding it exits.
+type FileLease struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileLease(path string) *FileLease {
+	return &FileLease{path: path}
+}
+
+// Acquire locks the file unless another process holds the lock. ttl is not used,
+// the lock is held until the process exits.
+func (l *FileLease) Acquire(_ context.Context, holder string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		return true, nil
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return false, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil
+		}
+		return false, err
+	}
+	// the holder is written for operators looking for the leader
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(holder+"\n"), 0)
+	}
+	l.file = file
+	return true, nil
+}
@@ -0,0 +1,67 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LeaseRequest asks the lease server for a lease, or to renew it.
+type LeaseRequest struct {
+	Holder     string `json:"holder"`
+	TtlSeconds int    `json:"ttl_seconds"`
+}
+
+// LeaseResponse is the current holder of a lease.
+type LeaseResponse struct {
+	Name      string    `json:"name"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// HttpLease takes a lease from a lease server, such as the stand-in served by `sunrise-data lease-server`.
+// PUT /leases/{name} grants the lease with 200, or answers 409 with its holder while another holder has it.
+type HttpLease struct {
+	url  string
+	name string
+}
+
+func NewHttpLease(serverUrl string, name string) *HttpLease {
+	return &HttpLease{url: strings.TrimSuffix(serverUrl, "/"), name: name}
+}
+
+func (l *HttpLease) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	body, err := json.Marshal(LeaseRequest{Holder: holder, TtlSeconds: int(ttl / time.Second)})
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, l.url+"/leases/"+url.PathEscape(l.name), bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var leaseResp LeaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&leaseResp); err != nil {
+		return false, fmt.Errorf("status %d: %w", resp.StatusCode, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return leaseResp.Holder == holder, nil
+	case http.StatusConflict:
+		return false, nil
+	}
+	return false, fmt.Errorf("status %d: %s", resp.StatusCode, leaseResp.Error)
+}
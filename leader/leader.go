@@ -0,0 +1,134 @@
+// Package leader elects the active instance among redundant validator instances, so that
+// only the leader submits txs while the others stand by to take over.
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sunriselayer/sunrise-data/config"
+)
+
+const (
+	BackendFile = "file"
+	BackendHttp = "http"
+
+	defaultLeaseName    = "sunrise-data-validator"
+	defaultLeaseTimeout = 15
+	defaultLockFile     = "validator.lock"
+)
+
+// ErrNotLeader is returned when a tx is not sent because this instance is standing by.
+var ErrNotLeader = errors.New("this instance is not the leader")
+
+// Lease is a backend granting a lease to a single holder at a time.
+type Lease interface {
+	// Acquire takes or renews the lease for holder for ttl. It returns false if another holder has it.
+	Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+}
+
+var (
+	enabled atomic.Bool
+	// leaderUntil is the unix nano time until which this instance holds the lease.
+	leaderUntil atomic.Int64
+)
+
+// Start elects the leader with the lease backend of the [leader] config. The lease is renewed
+// every third of lease_timeout, and a standby instance takes over within lease_timeout after
+// the leader stopped renewing it. Without a backend, this instance is always the leader.
+func Start(c config.Config) error {
+	conf := c.Leader
+	if conf.Backend == "" {
+		return nil
+	}
+
+	var lease Lease
+	switch conf.Backend {
+	case BackendFile:
+		lockFile := conf.LockFile
+		if lockFile == "" {
+			lockFile = defaultLockFile
+		}
+		lease = NewFileLease(lockFile)
+	case BackendHttp:
+		if conf.Url == "" {
+			return fmt.Errorf("url of the lease server is not configured")
+		}
+		name := conf.LeaseName
+		if name == "" {
+			name = defaultLeaseName
+		}
+		lease = NewHttpLease(conf.Url, name)
+	default:
+		return fmt.Errorf("unsupported leader backend %q, use %q or %q", conf.Backend, BackendFile, BackendHttp)
+	}
+
+	id := conf.Id
+	if id == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		id = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	timeout := conf.LeaseTimeout
+	if timeout <= 0 {
+		timeout = defaultLeaseTimeout
+	}
+	ttl := time.Duration(timeout) * time.Second
+
+	enabled.Store(true)
+	log.Info().Msgf("Leader election with the %s backend as %s, lease timeout %v sec", conf.Backend, id, timeout)
+	campaign(lease, id, ttl)
+	if !IsLeader() {
+		log.Info().Msg("Standing by, another instance is the leader")
+	}
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		for range ticker.C {
+			campaign(lease, id, ttl)
+		}
+	}()
+	return nil
+}
+
+// IsLeader tells if this instance may submit txs. It is always true without leader election.
+func IsLeader() bool {
+	if !enabled.Load() {
+		return true
+	}
+	return time.Now().UnixNano() < leaderUntil.Load()
+}
+
+// campaign takes or renews the lease once.
+func campaign(lease Lease, id string, ttl time.Duration) {
+	wasLeader := IsLeader()
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+	defer cancel()
+
+	acquired, err := lease.Acquire(ctx, id, ttl)
+	switch {
+	case err != nil:
+		// the leader steps down on its own when the lease is not renewed in time
+		log.Error().Msgf("Failed to renew the leader lease: %s", err)
+	case acquired:
+		// stop before the lease expires at the backend, leaving time for a failed renewal
+		leaderUntil.Store(start.Add(ttl * 2 / 3).UnixNano())
+	default:
+		leaderUntil.Store(0)
+	}
+
+	switch isLeader := IsLeader(); {
+	case isLeader && !wasLeader:
+		log.Info().Msgf("%s is now the leader", id)
+	case !isLeader && wasLeader:
+		log.Warn().Msgf("%s lost the leader lease, standing by", id)
+	}
+}
@@ -0,0 +1,81 @@
+package leader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// leaseServer keeps the leases of the stand-in lease server in memory.
+type leaseServer struct {
+	mu     sync.Mutex
+	leases map[string]LeaseResponse
+}
+
+// Serve runs a stand-in lease server for local networks. Leases are lost when it stops,
+// so production setups should use a lease server backed by a replicated store.
+func Serve(port int) error {
+	s := &leaseServer{leases: map[string]LeaseResponse{}}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/leases/{name}", s.acquire).Methods("PUT")
+	r.HandleFunc("/leases/{name}", s.get).Methods("GET")
+
+	log.Info().Msgf("Running lease server on localhost: %d", port)
+	return http.ListenAndServe(fmt.Sprintf(":%d", port), r)
+}
+
+func (s *leaseServer) acquire(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	var req LeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeLease(w, http.StatusBadRequest, LeaseResponse{Name: name, Error: err.Error()})
+		return
+	}
+	if req.Holder == "" || req.TtlSeconds <= 0 {
+		writeLease(w, http.StatusBadRequest, LeaseResponse{Name: name, Error: "holder and ttl_seconds are required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	lease, found := s.leases[name]
+	if found && lease.Holder != req.Holder && now.Before(lease.ExpiresAt) {
+		writeLease(w, http.StatusConflict, lease)
+		return
+	}
+	if !found || lease.Holder != req.Holder {
+		log.Info().Msgf("Lease %s is granted to %s", name, req.Holder)
+	}
+	lease = LeaseResponse{
+		Name:      name,
+		Holder:    req.Holder,
+		ExpiresAt: now.Add(time.Duration(req.TtlSeconds) * time.Second),
+	}
+	s.leases[name] = lease
+	writeLease(w, http.StatusOK, lease)
+}
+
+func (s *leaseServer) get(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	s.mu.Lock()
+	lease, found := s.leases[name]
+	s.mu.Unlock()
+	if !found || time.Now().After(lease.ExpiresAt) {
+		writeLease(w, http.StatusNotFound, LeaseResponse{Name: name, Error: "no holder"})
+		return
+	}
+	writeLease(w, http.StatusOK, lease)
+}
+
+func writeLease(w http.ResponseWriter, status int, res LeaseResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,161 @@
+package optimism
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf8"
+
+	altda "github.com/ethereum-optimism/optimism/op-alt-da"
+)
+
+// A generic commitment of the Optimism server is the altda generic commitment type byte,
+// the sunrise version byte, then a payload depending on the version.
+const (
+	// CommitmentVersion0 is followed by the metadata URI only. It is still decoded, but no longer created.
+	CommitmentVersion0 byte = 0x0c
+	// CommitmentVersion1 is followed by fields in the order of their tags, each a tag byte, a uvarint
+	// length and the value. The metadata URI is required, the protocol and the tx hash are optional.
+	CommitmentVersion1 byte = 0x0d
+)
+
+// Fields of a CommitmentVersion1 payload.
+const (
+	fieldMetadataUri byte = 0x01
+	fieldProtocol    byte = 0x02
+	fieldTxHash      byte = 0x03
+)
+
+const (
+	// maxMetadataUriLength bounds the metadata URI of a commitment.
+	maxMetadataUriLength = 1024
+	// maxProtocolLength bounds the protocol hint of a commitment.
+	maxProtocolLength = 32
+	// txHashLength is the length of the sha256 tx hash of MsgPublishData.
+	txHashLength = 32
+)
+
+// Commitment is the content of a generic commitment created by the Optimism server.
+type Commitment struct {
+	Version     byte
+	MetadataUri string
+	// Protocol is a hint of the protocol the data was published to, empty if unknown.
+	Protocol string
+	// TxHash is the hash of the MsgPublishData tx, empty if unknown.
+	TxHash []byte
+}
+
+// Encode returns the commitment with its altda generic commitment type prefix.
+func (c Commitment) Encode() ([]byte, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	data := []byte{c.Version}
+	switch c.Version {
+	case CommitmentVersion0:
+		data = append(data, c.MetadataUri...)
+	case CommitmentVersion1:
+		data = appendField(data, fieldMetadataUri, []byte(c.MetadataUri))
+		if c.Protocol != "" {
+			data = appendField(data, fieldProtocol, []byte(c.Protocol))
+		}
+		if len(c.TxHash) > 0 {
+			data = appendField(data, fieldTxHash, c.TxHash)
+		}
+	}
+	return altda.NewGenericCommitment(data).Encode(), nil
+}
+
+// DecodeCommitment decodes a commitment encoded by Encode. Every error wraps altda.ErrInvalidCommitment.
+func DecodeCommitment(comm []byte) (Commitment, error) {
+	if len(comm) < 3 {
+		return Commitment{}, invalidCommitment("%d bytes are too short", len(comm))
+	}
+	if altda.CommitmentType(comm[0]) != altda.GenericCommitmentType {
+		return Commitment{}, invalidCommitment("commitment type %d is not generic", comm[0])
+	}
+
+	c := Commitment{Version: comm[1]}
+	payload := comm[2:]
+	switch c.Version {
+	case CommitmentVersion0:
+		c.MetadataUri = string(payload)
+	case CommitmentVersion1:
+		var lastTag byte
+		for len(payload) > 0 {
+			tag := payload[0]
+			length, n := binary.Uvarint(payload[1:])
+			// only the shortest encoding of the length is accepted, so that a commitment has a single encoding
+			if n <= 0 || n != len(binary.AppendUvarint(nil, length)) {
+				return Commitment{}, invalidCommitment("malformed length of field %d", tag)
+			}
+			payload = payload[1+n:]
+			if length > uint64(len(payload)) {
+				return Commitment{}, invalidCommitment("field %d of %d bytes exceeds the commitment", tag, length)
+			}
+			if length == 0 {
+				return Commitment{}, invalidCommitment("empty field %d", tag)
+			}
+			// fields are in the order of their tags, once each
+			if tag <= lastTag {
+				return Commitment{}, invalidCommitment("field %d is duplicate or out of order", tag)
+			}
+			lastTag = tag
+
+			value := payload[:length]
+			payload = payload[length:]
+			switch tag {
+			case fieldMetadataUri:
+				c.MetadataUri = string(value)
+			case fieldProtocol:
+				c.Protocol = string(value)
+			case fieldTxHash:
+				c.TxHash = append([]byte{}, value...)
+			default:
+				return Commitment{}, invalidCommitment("unknown field %d", tag)
+			}
+		}
+	default:
+		return Commitment{}, invalidCommitment("unsupported sunrise version %#x", c.Version)
+	}
+
+	if err := c.validate(); err != nil {
+		return Commitment{}, err
+	}
+	return c, nil
+}
+
+func (c Commitment) validate() error {
+	if c.Version != CommitmentVersion0 && c.Version != CommitmentVersion1 {
+		return invalidCommitment("unsupported sunrise version %#x", c.Version)
+	}
+	if c.MetadataUri == "" {
+		return invalidCommitment("no metadata URI")
+	}
+	if len(c.MetadataUri) > maxMetadataUriLength || !utf8.ValidString(c.MetadataUri) {
+		return invalidCommitment("malformed metadata URI")
+	}
+	if c.Version == CommitmentVersion0 {
+		if c.Protocol != "" || len(c.TxHash) > 0 {
+			return invalidCommitment("version %#x only carries the metadata URI", c.Version)
+		}
+		return nil
+	}
+	if len(c.Protocol) > maxProtocolLength || !utf8.ValidString(c.Protocol) {
+		return invalidCommitment("malformed protocol")
+	}
+	if len(c.TxHash) > 0 && len(c.TxHash) != txHashLength {
+		return invalidCommitment("tx hash of %d bytes", len(c.TxHash))
+	}
+	return nil
+}
+
+func appendField(data []byte, tag byte, value []byte) []byte {
+	data = append(data, tag)
+	data = binary.AppendUvarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+func invalidCommitment(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", altda.ErrInvalidCommitment, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,113 @@
+package optimism
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	altda "github.com/ethereum-optimism/optimism/op-alt-da"
+)
+
+const testMetadataUri = "ipfs://bafkreigh2akiscaildcqabsyg3dfr6chu3fgpregiymsck7e7aqa4s52zy"
+
+// legacyCommitment is a commitment created before the commitments were versioned.
+func legacyCommitment(metadataUri string) []byte {
+	return altda.NewGenericCommitment(append([]byte{CommitmentVersion0}, metadataUri...)).Encode()
+}
+
+func TestDecodeLegacyCommitment(t *testing.T) {
+	c, err := DecodeCommitment(legacyCommitment(testMetadataUri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != CommitmentVersion0 || c.MetadataUri != testMetadataUri {
+		t.Fatalf("unexpected commitment %+v", c)
+	}
+}
+
+func TestCommitmentRoundTrip(t *testing.T) {
+	commitments := []Commitment{
+		{Version: CommitmentVersion0, MetadataUri: testMetadataUri},
+		{Version: CommitmentVersion1, MetadataUri: testMetadataUri},
+		{Version: CommitmentVersion1, MetadataUri: testMetadataUri, Protocol: "ipfs"},
+		{Version: CommitmentVersion1, MetadataUri: testMetadataUri, Protocol: "arweave", TxHash: bytes.Repeat([]byte{0xab}, txHashLength)},
+	}
+	for _, c := range commitments {
+		comm, err := c.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeCommitment(comm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Version != c.Version || decoded.MetadataUri != c.MetadataUri || decoded.Protocol != c.Protocol || !bytes.Equal(decoded.TxHash, c.TxHash) {
+			t.Fatalf("decoded %+v, expected %+v", decoded, c)
+		}
+	}
+}
+
+func TestDecodeMalformedCommitment(t *testing.T) {
+	v1, err := Commitment{Version: CommitmentVersion1, MetadataUri: testMetadataUri}.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	malformed := map[string][]byte{
+		"empty":            nil,
+		"type only":        {byte(altda.GenericCommitmentType)},
+		"no payload":       {byte(altda.GenericCommitmentType), CommitmentVersion1},
+		"keccak type":      append([]byte{byte(altda.Keccak256CommitmentType), CommitmentVersion0}, testMetadataUri...),
+		"unknown version":  append([]byte{byte(altda.GenericCommitmentType), 0xff}, testMetadataUri...),
+		"truncated field":  v1[:len(v1)-1],
+		"trailing byte":    append(append([]byte{}, v1...), 0x01),
+		"unknown field":    append(append([]byte{}, v1...), 0x7f, 0x01, 0x00),
+		"duplicate field":  append(append([]byte{}, v1...), v1[2:]...),
+		"out of order":     append([]byte{byte(altda.GenericCommitmentType), CommitmentVersion1, fieldProtocol, 0x04, 'i', 'p', 'f', 's'}, v1[2:]...),
+		"empty field":      append(append([]byte{}, v1...), fieldProtocol, 0x00),
+		"long length":      {byte(altda.GenericCommitmentType), CommitmentVersion1, fieldMetadataUri, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+		"padded length":    {byte(altda.GenericCommitmentType), CommitmentVersion1, fieldMetadataUri, 0x81, 0x00, 'a'},
+		"no metadata uri":  {byte(altda.GenericCommitmentType), CommitmentVersion1, fieldProtocol, 0x04, 'i', 'p', 'f', 's'},
+		"short tx hash":    append(append([]byte{}, v1...), fieldTxHash, 0x01, 0x00),
+		"invalid utf8 uri": {byte(altda.GenericCommitmentType), CommitmentVersion0, 0xff, 0xfe},
+		"empty legacy uri": {byte(altda.GenericCommitmentType), CommitmentVersion0},
+	}
+	for name, comm := range malformed {
+		if _, err := DecodeCommitment(comm); !errors.Is(err, altda.ErrInvalidCommitment) {
+			t.Errorf("%s: expected an invalid commitment error, got %v", name, err)
+		}
+	}
+}
+
+func FuzzDecodeCommitment(f *testing.F) {
+	f.Add(legacyCommitment(testMetadataUri))
+	for _, c := range []Commitment{
+		{Version: CommitmentVersion1, MetadataUri: testMetadataUri},
+		{Version: CommitmentVersion1, MetadataUri: testMetadataUri, Protocol: "ipfs", TxHash: bytes.Repeat([]byte{0x01}, txHashLength)},
+	} {
+		comm, err := c.Encode()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(comm)
+	}
+	f.Add([]byte{})
+	f.Add([]byte{byte(altda.GenericCommitmentType), CommitmentVersion1, fieldMetadataUri, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+
+	f.Fuzz(func(t *testing.T, comm []byte) {
+		c, err := DecodeCommitment(comm)
+		if err != nil {
+			if !errors.Is(err, altda.ErrInvalidCommitment) {
+				t.Fatalf("error does not wrap ErrInvalidCommitment: %v", err)
+			}
+			return
+		}
+		// a decoded commitment has a single encoding
+		encoded, err := c.Encode()
+		if err != nil {
+			t.Fatalf("failed to encode decoded commitment %+v: %v", c, err)
+		}
+		if !bytes.Equal(encoded, comm) {
+			t.Fatalf("commitment %x is encoded again as %x", comm, encoded)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/ethereum-optimism/optimism/op-service/opio"
 	"github.com/rs/zerolog/log"
+	"github.com/sunriselayer/sunrise-data/api"
 	"github.com/sunriselayer/sunrise-data/config"
 )
 
@@ -20,12 +21,24 @@ func StartDAServer() error {
 		return err
 	}
 
-	log.Info().Msgf("Starting Alt DA Server... listen_address: %s, port: %d, data_shard_count: %d, parity_shard_count: %d", config.Optimism.ListenAddress, config.Optimism.Port, config.Optimism.DataShardCount, config.Optimism.ParityShardCount)
+	protocol, err := api.PublishProtocol(config.Optimism.Protocol, config.Optimism.ReplicationTargets)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Starting Alt DA Server... listen_address: %s, port: %d, data_shard_count: %d, parity_shard_count: %d, protocol: %s, replication_targets: %v", config.Optimism.ListenAddress, config.Optimism.Port, config.Optimism.DataShardCount, config.Optimism.ParityShardCount, protocol, config.Optimism.ReplicationTargets)
 	storeConfig := SunriseConfig{
-		DataShardCount:   config.Optimism.DataShardCount,
-		ParityShardCount: config.Optimism.ParityShardCount,
+		DataShardCount:     config.Optimism.DataShardCount,
+		ParityShardCount:   config.Optimism.ParityShardCount,
+		Protocol:           protocol,
+		ReplicationTargets: config.Optimism.ReplicationTargets,
+		ReplicationTimeout: config.Optimism.ReplicationTimeout,
+	}
+	keccakIndex, err := OpenKeccakIndex(config.Optimism.KeccakIndexDir)
+	if err != nil {
+		return err
 	}
-	store := NewSunriseStore(storeConfig)
+	store := NewSunriseStore(storeConfig, keccakIndex)
 	server := NewSunriseServer(config.Optimism.ListenAddress, config.Optimism.Port, store)
 
 	if err := server.Start(); err != nil {
@@ -0,0 +1,70 @@
+package optimism
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	altda "github.com/ethereum-optimism/optimism/op-alt-da"
+)
+
+const defaultKeccakIndexDir = "optimism-keccak"
+
+// KeccakIndex persists the metadata URI of the data published for each keccak256 commitment,
+// since a keccak256 commitment does not carry the metadata URI like a generic commitment does.
+// Every entry is a file named after the hex hash, written atomically.
+type KeccakIndex struct {
+	dir string
+}
+
+// OpenKeccakIndex opens the index in dir, creating it if needed.
+func OpenKeccakIndex(dir string) (*KeccakIndex, error) {
+	if dir == "" {
+		dir = defaultKeccakIndexDir
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create keccak index dir %s: %w", dir, err)
+	}
+	return &KeccakIndex{dir: dir}, nil
+}
+
+// Get returns the metadata URI of comm, or altda.ErrNotFound if nothing was published for it.
+func (k *KeccakIndex) Get(comm altda.Keccak256Commitment) (string, error) {
+	bz, err := os.ReadFile(k.path(comm))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", altda.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bz)), nil
+}
+
+// Put saves metadataUri as the data published for comm.
+func (k *KeccakIndex) Put(comm altda.Keccak256Commitment, metadataUri string) error {
+	tmp, err := os.CreateTemp(k.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(metadataUri); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), k.path(comm))
+}
+
+func (k *KeccakIndex) path(comm altda.Keccak256Commitment) string {
+	return filepath.Join(k.dir, hex.EncodeToString(comm))
+}
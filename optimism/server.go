@@ -97,6 +97,10 @@ func (d *SunriseServer) HandleGet(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if err != nil && errors.Is(err, altda.ErrInvalidCommitment) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -110,8 +114,8 @@ func (d *SunriseServer) HandleGet(w http.ResponseWriter, r *http.Request) {
 func (d *SunriseServer) HandlePut(w http.ResponseWriter, r *http.Request) {
 	log.Debug().Msgf("PUT: %s", r.URL)
 
-	route := path.Base(r.URL.Path)
-	if route != "put" {
+	route := path.Dir(r.URL.Path)
+	if route != "/put" && r.URL.Path != "/put" {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -122,17 +126,39 @@ func (d *SunriseServer) HandlePut(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	comm, err := d.store.Put(r.Context(), input)
+	if r.URL.Path == "/put" || r.URL.Path == "/put/" {
+		// without commitment, the generic commitment of the published data is returned
+		comm, err := d.store.Put(r.Context(), input)
+		if err != nil {
+			key := hexutil.Encode(comm)
+			log.Error().Msgf("Failed to store commitment to the DA server: %s, key: %s", err, key)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if _, err := w.Write(comm); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	// with a keccak256 commitment computed by the batcher
+	key := path.Base(r.URL.Path)
+	comm, err := hexutil.Decode(key)
 	if err != nil {
-		key := hexutil.Encode(comm)
-		log.Error().Msgf("Failed to store commitment to the DA server: %s, key: %s", err, key)
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	if _, err := w.Write(comm); err != nil {
+	if err := d.store.PutKeccak(r.Context(), comm, input); err != nil {
+		log.Error().Msgf("Failed to store commitment to the DA server: %s, key: %s", err, key)
+		if errors.Is(err, altda.ErrInvalidCommitment) || errors.Is(err, altda.ErrCommitmentMismatch) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	w.WriteHeader(http.StatusOK)
 }
 
 func (b *SunriseServer) Endpoint() string {
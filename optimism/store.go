@@ -3,47 +3,89 @@ package optimism
 import (
 	"context"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
 	altda "github.com/ethereum-optimism/optimism/op-alt-da"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+
 	api "github.com/sunriselayer/sunrise-data/api"
 )
 
-const VersionByte = 0x0c
+// getBlobData and publishData reach the sunrise-data API, and are replaced in tests.
+var (
+	getBlobData = api.GetBlobData
+	publishData = api.PublishData
+)
 
 type SunriseConfig struct {
-	DataShardCount   int
-	ParityShardCount int
+	DataShardCount     int
+	ParityShardCount   int
+	Protocol           string
+	ReplicationTargets []string
+	ReplicationTimeout int
 }
 
 // SunriseStore implements DAStorage with sunrise-data backend
 type SunriseStore struct {
-	Config     SunriseConfig
-	GetTimeout time.Duration
-	Namespace  []byte
+	Config      SunriseConfig
+	GetTimeout  time.Duration
+	Namespace   []byte
+	KeccakIndex *KeccakIndex
+
+	// keccakPuts makes the concurrent puts of a keccak256 commitment share a single publish.
+	keccakPuts singleflight.Group
 }
 
 // NewSunriseStore returns a sunrise store.
-func NewSunriseStore(cfg SunriseConfig) *SunriseStore {
+func NewSunriseStore(cfg SunriseConfig, keccakIndex *KeccakIndex) *SunriseStore {
 	return &SunriseStore{
-		Config:     cfg,
-		GetTimeout: time.Minute,
+		Config:      cfg,
+		GetTimeout:  time.Minute,
+		KeccakIndex: keccakIndex,
 	}
 }
 
 func (d *SunriseStore) Get(ctx context.Context, comm []byte) ([]byte, error) {
-	commExtracted, err := Decode(comm)
+	commitment, err := altda.DecodeCommitmentData(comm)
+	if err != nil {
+		return nil, fmt.Errorf("sunrise-alt-da: failed to decode commitment: %w", err)
+	}
+	if keccakComm, ok := commitment.(altda.Keccak256Commitment); ok {
+		return d.getKeccak(keccakComm)
+	}
+
+	c, err := DecodeCommitment(comm)
 	if err != nil {
 		return nil, fmt.Errorf("sunrise-alt-da: failed to decode payload: %w", err)
 	}
+	return d.getBlob(c.MetadataUri)
+}
+
+// getKeccak returns the data published for a keccak256 commitment, checked against its hash.
+func (d *SunriseStore) getKeccak(comm altda.Keccak256Commitment) ([]byte, error) {
+	metadataUri, err := d.KeccakIndex.Get(comm)
+	if err != nil {
+		return nil, fmt.Errorf("sunrise-alt-da: failed to find commitment %s: %w", comm, err)
+	}
+	input, err := d.getBlob(metadataUri)
+	if err != nil {
+		return nil, err
+	}
+	if err := comm.Verify(input); err != nil {
+		return nil, fmt.Errorf("sunrise-alt-da: blob of %s does not match commitment %s: %w", metadataUri, comm, err)
+	}
+	return input, nil
+}
 
-	metadataUri := string(commExtracted)
+func (d *SunriseStore) getBlob(metadataUri string) ([]byte, error) {
 	log.Info().Msgf("sunrise-alt-da: blob request: %s", metadataUri)
 
 	_, cancel := context.WithTimeout(context.Background(), d.GetTimeout)
-	res, err := api.GetBlobData(metadataUri)
+	res, err := getBlobData(metadataUri)
 	cancel()
 
 	if err != nil {
@@ -64,28 +106,75 @@ func (d *SunriseStore) Get(ctx context.Context, comm []byte) ([]byte, error) {
 	return input, nil
 }
 
+// Put publishes data and returns its generic commitment, which embeds the metadata URI.
 func (d *SunriseStore) Put(ctx context.Context, data []byte) ([]byte, error) {
-	req := api.PublishRequest{
-		Blob:             base64.StdEncoding.EncodeToString(data),
-		DataShardCount:   d.Config.DataShardCount,
-		ParityShardCount: d.Config.ParityShardCount,
-		Protocol:         "ipfs",
+	res, err := d.publish(data)
+	if err != nil {
+		return nil, err
+	}
+	c := Commitment{
+		Version:     CommitmentVersion1,
+		MetadataUri: res.MetadataUri,
+		Protocol:    d.Config.Protocol,
+	}
+	// the tx hash is only a hint, so a commitment is still returned without it
+	if txHash, err := hex.DecodeString(res.TxHash); err == nil && len(txHash) == txHashLength {
+		c.TxHash = txHash
 	}
+	return c.Encode()
+}
 
-	res, err := api.PublishData(req)
+// PutKeccak publishes data for a keccak256 commitment computed by the batcher, and saves the
+// metadata URI of the commitment. Data already published for the commitment is not published again.
+func (d *SunriseStore) PutKeccak(ctx context.Context, comm []byte, data []byte) error {
+	commitment, err := altda.DecodeCommitmentData(comm)
 	if err != nil {
-		return nil, fmt.Errorf("sunrise-alt-da: failed to post publish request: %w", err)
+		return fmt.Errorf("sunrise-alt-da: failed to decode commitment: %w", err)
+	}
+	keccakComm, ok := commitment.(altda.Keccak256Commitment)
+	if !ok {
+		return fmt.Errorf("sunrise-alt-da: %w: only keccak256 commitments are put with a commitment", altda.ErrInvalidCommitment)
+	}
+	if err := keccakComm.Verify(data); err != nil {
+		return fmt.Errorf("sunrise-alt-da: data does not match commitment %s: %w", keccakComm, err)
 	}
 
-	log.Info().Msgf("sunrise-alt-da: blob successfully submitted: tx_hash: %s, uri: %s", res.TxHash, res.MetadataUri)
-	commitment := altda.NewGenericCommitment(append([]byte{VersionByte}, []byte(res.MetadataUri)...))
-
-	return commitment.Encode(), nil
+	// without a lock, a put arriving before the commitment is indexed would publish the data again
+	_, err, _ = d.keccakPuts.Do(keccakComm.String(), func() (any, error) {
+		if metadataUri, err := d.KeccakIndex.Get(keccakComm); err == nil {
+			log.Info().Msgf("sunrise-alt-da: commitment %s was already published: uri: %s", keccakComm, metadataUri)
+			return nil, nil
+		} else if !errors.Is(err, altda.ErrNotFound) {
+			return nil, fmt.Errorf("sunrise-alt-da: failed to read commitment %s: %w", keccakComm, err)
+		}
+
+		res, err := d.publish(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.KeccakIndex.Put(keccakComm, res.MetadataUri); err != nil {
+			return nil, fmt.Errorf("sunrise-alt-da: failed to save commitment %s of %s: %w", keccakComm, res.MetadataUri, err)
+		}
+		return nil, nil
+	})
+	return err
 }
 
-func Decode(comm []byte) ([]byte, error) {
-	if comm[0] != 0x01 && comm[1] != VersionByte {
-		return nil, fmt.Errorf("invalid encoding")
+func (d *SunriseStore) publish(data []byte) (api.PublishResponse, error) {
+	req := api.PublishRequest{
+		Blob:               base64.StdEncoding.EncodeToString(data),
+		DataShardCount:     d.Config.DataShardCount,
+		ParityShardCount:   d.Config.ParityShardCount,
+		Protocol:           d.Config.Protocol,
+		ReplicationTargets: d.Config.ReplicationTargets,
+		ReplicationTimeout: d.Config.ReplicationTimeout,
 	}
-	return comm[2:], nil
+
+	res, err := publishData(req)
+	if err != nil {
+		return api.PublishResponse{}, fmt.Errorf("sunrise-alt-da: failed to post publish request: %w", err)
+	}
+
+	log.Info().Msgf("sunrise-alt-da: blob successfully submitted: tx_hash: %s, uri: %s", res.TxHash, res.MetadataUri)
+	return res, nil
 }
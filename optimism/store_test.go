@@ -0,0 +1,138 @@
+package optimism
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	altda "github.com/ethereum-optimism/optimism/op-alt-da"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	api "github.com/sunriselayer/sunrise-data/api"
+)
+
+func TestKeccakIndexRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	index, err := OpenKeccakIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comm := altda.NewKeccak256Commitment([]byte("frame"))
+	if _, err := index.Get(comm); !errors.Is(err, altda.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before the commitment is put, got %v", err)
+	}
+	if err := index.Put(comm, testMetadataUri); err != nil {
+		t.Fatal(err)
+	}
+
+	// the index is kept across restarts
+	reopened, err := OpenKeccakIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadataUri, err := reopened.Get(comm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadataUri != testMetadataUri {
+		t.Fatalf("got %s, expected %s", metadataUri, testMetadataUri)
+	}
+	if _, err := reopened.Get(altda.NewKeccak256Commitment([]byte("other frame"))); !errors.Is(err, altda.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another commitment, got %v", err)
+	}
+}
+
+// stubBlob makes the store retrieve blob for every metadata URI.
+func stubBlob(t *testing.T, blob []byte) {
+	get := getBlobData
+	t.Cleanup(func() { getBlobData = get })
+	getBlobData = func(string) (api.GetBlobResponse, error) {
+		return api.GetBlobResponse{Blob: base64.StdEncoding.EncodeToString(blob)}, nil
+	}
+}
+
+func testStore(t *testing.T) *SunriseStore {
+	index, err := OpenKeccakIndex(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSunriseStore(SunriseConfig{}, index)
+}
+
+func TestGetKeccak(t *testing.T) {
+	store := testStore(t)
+	server := NewSunriseServer("localhost", 0, store)
+	data := []byte("frame")
+	comm := altda.NewKeccak256Commitment(data)
+	if err := store.KeccakIndex.Put(comm, testMetadataUri); err != nil {
+		t.Fatal(err)
+	}
+
+	stubBlob(t, data)
+	rec := httptest.NewRecorder()
+	server.HandleGet(rec, httptest.NewRequest(http.MethodGet, "/get/"+hexutil.Encode(comm.Encode()), nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != string(data) {
+		t.Fatalf("got %d %q, expected the blob", rec.Code, rec.Body.String())
+	}
+
+	// a blob that does not hash to the commitment is never returned
+	stubBlob(t, []byte("tampered frame"))
+	rec = httptest.NewRecorder()
+	server.HandleGet(rec, httptest.NewRequest(http.MethodGet, "/get/"+hexutil.Encode(comm.Encode()), nil))
+	if rec.Code == http.StatusOK || rec.Body.Len() != 0 {
+		t.Fatalf("got %d %q for a mismatched blob", rec.Code, rec.Body.String())
+	}
+
+	// an unknown commitment is not found
+	rec = httptest.NewRecorder()
+	server.HandleGet(rec, httptest.NewRequest(http.MethodGet, "/get/"+hexutil.Encode(altda.NewKeccak256Commitment([]byte("other")).Encode()), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("got %d for an unknown commitment, expected %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestPutKeccakPublishesOnce(t *testing.T) {
+	store := testStore(t)
+	var published atomic.Int32
+	publish := publishData
+	t.Cleanup(func() { publishData = publish })
+	publishData = func(api.PublishRequest) (api.PublishResponse, error) {
+		published.Add(1)
+		// keep the publish in flight while the other puts arrive
+		time.Sleep(50 * time.Millisecond)
+		return api.PublishResponse{MetadataUri: testMetadataUri}, nil
+	}
+
+	data := []byte("frame")
+	comm := altda.NewKeccak256Commitment(data)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.PutKeccak(context.Background(), comm.Encode(), data); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := store.PutKeccak(context.Background(), comm.Encode(), data); err != nil {
+		t.Fatal(err)
+	}
+	if n := published.Load(); n != 1 {
+		t.Fatalf("published %d times, expected once", n)
+	}
+	if metadataUri, err := store.KeccakIndex.Get(comm); err != nil || metadataUri != testMetadataUri {
+		t.Fatalf("got %q, %v from the index", metadataUri, err)
+	}
+
+	if err := store.PutKeccak(context.Background(), comm.Encode(), []byte("other frame")); err == nil {
+		t.Fatal("data not matching the commitment is accepted")
+	}
+}
@@ -1,6 +1,8 @@
 package protocols
 
 import (
+	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 
@@ -68,3 +70,20 @@ func (arweave *Arweave) Retrieve(uri string) (shards []byte, err error) {
 	arweaveClient := goar.NewClient("https://arweave.net")
 	return arweaveClient.GetTransactionData(strings.Replace(uri, "ar://", "", 1))
 }
+
+// RetrieveLimited reads the data of uri from the gateway as it downloads, so that data over limit is never buffered.
+func (arweave *Arweave) RetrieveLimited(uri string, limit int64) (data []byte, err error) {
+	resp, err := http.Get("https://arweave.net/" + strings.Replace(uri, "ar://", "", 1))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("arweave gateway returned %s", resp.Status)
+	}
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, resp.ContentLength)
+	}
+	return readLimited(resp.Body, limit)
+}
@@ -4,15 +4,19 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/ipfs/boxo/files"
 	"github.com/ipfs/boxo/path"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/kubo/client/rpc"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
 
 	scontext "github.com/sunriselayer/sunrise-data/context"
 )
@@ -20,7 +24,10 @@ import (
 type Ipfs struct {
 }
 
-var _ Protocol = &Ipfs{}
+var (
+	_ Protocol = &Ipfs{}
+	_ Pinner   = &Ipfs{}
+)
 
 func uploadToIpfs(inputData []byte) (string, error) {
 	var err error
@@ -86,34 +93,120 @@ func (ipfs *Ipfs) PublishMetadata(metadata []byte) (uri string, err error) {
 }
 
 func (ipfs *Ipfs) Retrieve(uri string) (shards []byte, err error) {
-	var node *rpc.HttpApi
-	if scontext.Config.Api.IpfsApiUrl != "" {
-		node, err = rpc.NewURLApiWithClient(scontext.Config.Api.IpfsApiUrl, &http.Client{
-			Transport: &http.Transport{
-				Proxy:             http.ProxyFromEnvironment,
-				DisableKeepAlives: true,
-			},
-		})
-	} else {
-		node, err = rpc.NewLocalApi()
+	r, err := ipfsFile(uri)
+	if err != nil {
+		return nil, err
 	}
+	defer r.Close()
 
+	return io.ReadAll(r)
+}
+
+func (ipfs *Ipfs) RetrieveLimited(uri string, limit int64) (data []byte, err error) {
+	r, err := ipfsFile(uri)
 	if err != nil {
 		return nil, err
 	}
-	ctx := context.Background()
-	cidData, err := cid.Decode(strings.Replace(uri, "ipfs://", "", 1))
+	defer r.Close()
+
+	if size, err := r.Size(); err == nil && size > limit {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, size)
+	}
+	return readLimited(r, limit)
+}
+
+// ipfsFile opens the file of uri on the IPFS node.
+func ipfsFile(uri string) (files.File, error) {
+	node, p, err := ipfsNodePath(uri)
 	if err != nil {
 		return nil, err
 	}
-	data, err := node.Unixfs().Get(ctx, path.FromCid(cidData))
+	data, err := node.Unixfs().Get(context.Background(), p)
 	if err != nil {
 		return nil, err
 	}
 	r, ok := data.(files.File)
 	if !ok {
+		data.Close()
 		return nil, errors.New("incorrect type from Unixfs().Get()")
 	}
+	return r, nil
+}
 
-	return io.ReadAll(r)
+func (ipfs *Ipfs) Pin(uri string) error {
+	node, p, err := ipfsNodePath(uri)
+	if err != nil {
+		return err
+	}
+	return node.Pin().Add(context.Background(), p)
+}
+
+func (ipfs *Ipfs) Unpin(uri string) error {
+	node, p, err := ipfsNodePath(uri)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if _, pinned, err := node.Pin().IsPinned(ctx, p); err != nil || !pinned {
+		return err
+	}
+	return node.Pin().Rm(ctx, p)
+}
+
+// ReplicateIpfs pins uris on the IPFS node of apiUrl, so that the data stays available from
+// that node too. The node is first connected to ipfs_address_info to fetch the data from it.
+// An error is returned if the uris are not pinned within timeout.
+func ReplicateIpfs(apiUrl string, uris []string, timeout time.Duration) error {
+	node, err := ipfsNode(apiUrl)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if scontext.Config.Api.IpfsAddressInfo != "" {
+		if addrInfo, err := peer.AddrInfoFromString(scontext.Config.Api.IpfsAddressInfo); err == nil {
+			if err := node.Swarm().Connect(ctx, *addrInfo); err != nil {
+				log.Warn().Msgf("Failed to connect %s to %s: %s", apiUrl, scontext.Config.Api.IpfsAddressInfo, err)
+			}
+		}
+	}
+	for _, uri := range uris {
+		cidData, err := cid.Decode(strings.Replace(uri, "ipfs://", "", 1))
+		if err != nil {
+			return err
+		}
+		if err := node.Pin().Add(ctx, path.FromCid(cidData)); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("failed to pin %s on %s within %v: %w", uri, apiUrl, timeout, ctx.Err())
+			}
+			return fmt.Errorf("failed to pin %s on %s: %w", uri, apiUrl, err)
+		}
+	}
+	return nil
+}
+
+// ipfsNodePath connects to the IPFS node and returns the path of uri.
+func ipfsNodePath(uri string) (*rpc.HttpApi, path.ImmutablePath, error) {
+	node, err := ipfsNode(scontext.Config.Api.IpfsApiUrl)
+	if err != nil {
+		return nil, path.ImmutablePath{}, err
+	}
+	cidData, err := cid.Decode(strings.Replace(uri, "ipfs://", "", 1))
+	if err != nil {
+		return nil, path.ImmutablePath{}, err
+	}
+	return node, path.FromCid(cidData), nil
+}
+
+// ipfsNode connects to the IPFS node of apiUrl, or to the local node if it is empty.
+func ipfsNode(apiUrl string) (*rpc.HttpApi, error) {
+	if apiUrl == "" {
+		return rpc.NewLocalApi()
+	}
+	return rpc.NewURLApiWithClient(apiUrl, &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			DisableKeepAlives: true,
+		},
+	})
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -24,6 +25,30 @@ type Protocol interface {
 	PublishShards(inputData [][]byte) (uris []string, err error)
 	PublishMetadata(metadata []byte) (uri string, err error)
 	Retrieve(uri string) (shards []byte, err error)
+	// RetrieveLimited is Retrieve that stops reading once the data exceeds limit bytes and returns ErrTooLarge.
+	RetrieveLimited(uri string, limit int64) (data []byte, err error)
+}
+
+// ErrTooLarge is returned by RetrieveLimited for data bigger than its limit.
+var ErrTooLarge = errors.New("retrieved data exceeds the size limit")
+
+// readLimited reads r up to limit bytes, without buffering more than one byte over it.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, limit)
+	}
+	return data, nil
+}
+
+// Pinner is a protocol whose node can keep retrieved data locally until it is unpinned.
+type Pinner interface {
+	Pin(uri string) error
+	// Unpin releases the pin of uri. It succeeds if uri is not pinned.
+	Unpin(uri string) error
 }
 
 func GetPublishProtocol(protocol string) (Protocol, error) {
@@ -71,27 +96,25 @@ func ConnectSwarm(addrInfo peer.AddrInfo) error {
 }
 
 func CheckIpfsConnection() error {
-	var err error
-	var node *rpc.HttpApi
-
-	// connect ipfs node remote or local
-	if scontext.Config.Api.IpfsApiUrl != "" {
-		node, err = rpc.NewURLApiWithClient(scontext.Config.Api.IpfsApiUrl, nil)
-	} else {
-		node, err = rpc.NewLocalApi()
+	if err := PingIpfs(context.Background()); err != nil {
+		return err
 	}
+	log.Info().Msg("Successfully connected to ipfs daemon")
+	return nil
+}
 
+// PingIpfs checks that the IPFS daemon answers before ctx is done.
+func PingIpfs(ctx context.Context) error {
+	// connect ipfs node remote or local
+	node, err := ipfsNode(scontext.Config.Api.IpfsApiUrl)
 	if err != nil {
 		return fmt.Errorf("failed to connect to ipfs daemon: %w", err)
 	}
 
 	// check ipfs node status
-	ctx := context.Background()
 	_, err = node.Swarm().Peers(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get peers from ipfs daemon: %w", err)
 	}
-
-	log.Info().Msg("Successfully connected to ipfs daemon")
 	return nil
 }
@@ -1,15 +1,19 @@
 package rollkit
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/json"
+	"fmt"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/rollkit/go-da"
 	"github.com/rs/zerolog/log"
 	"github.com/sunriselayer/sunrise-data/api"
 	"github.com/sunriselayer/sunrise-data/config"
+	"github.com/sunriselayer/sunrise-data/consts"
 )
 
 type SunriseDA struct {
@@ -78,15 +82,15 @@ func (sunrise *SunriseDA) Submit(ctx context.Context, daBlobs []da.Blob, gasPric
 
 func (sunrise *SunriseDA) SubmitWithOptions(ctx context.Context, daBlobs []da.Blob, gasPrice float64, namespace da.Namespace, options []byte) ([]da.ID, error) {
 	var ids []da.ID
-	log.Info().Msgf("Submitting %d blobs", len(daBlobs))
+	req, err := sunrise.publishRequest(options)
+	if err != nil {
+		log.Error().Msgf("Invalid submit options: %s", err)
+		return nil, err
+	}
+	log.Info().Msgf("Submitting %d blobs to %s with %d data and %d parity shards", len(daBlobs), req.Protocol, req.DataShardCount, req.ParityShardCount)
 	for _, blob := range daBlobs {
 		encodedBlob := base64.StdEncoding.EncodeToString(blob)
-		req := api.PublishRequest{
-			Blob:             encodedBlob,
-			DataShardCount:   int(sunrise.config.Rollkit.DataShardCount),
-			ParityShardCount: int(sunrise.config.Rollkit.ParityShardCount),
-			Protocol:         "ipfs",
-		}
+		req.Blob = encodedBlob
 		res, err := api.PublishData(req)
 		if err != nil {
 			log.Error().Msgf("Failed to publish blob %s", err)
@@ -103,6 +107,55 @@ func (sunrise *SunriseDA) SubmitWithOptions(ctx context.Context, daBlobs []da.Bl
 	return ids, nil
 }
 
+// SubmitOptions are the JSON options of a submission, which override the [rollkit] config.
+type SubmitOptions struct {
+	Protocol         string `json:"protocol,omitempty"`
+	DataShardCount   int    `json:"data_shard_count,omitempty"`
+	ParityShardCount int    `json:"parity_shard_count,omitempty"`
+}
+
+// publishRequest returns the publish request of a submission with options, without its blob.
+func (sunrise *SunriseDA) publishRequest(options []byte) (api.PublishRequest, error) {
+	req := api.PublishRequest{
+		DataShardCount:     sunrise.config.Rollkit.DataShardCount,
+		ParityShardCount:   sunrise.config.Rollkit.ParityShardCount,
+		Protocol:           sunrise.config.Rollkit.Protocol,
+		ReplicationTargets: sunrise.config.Rollkit.ReplicationTargets,
+		ReplicationTimeout: sunrise.config.Rollkit.ReplicationTimeout,
+	}
+	if len(options) > 0 {
+		var opts SubmitOptions
+		decoder := json.NewDecoder(bytes.NewReader(options))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&opts); err != nil {
+			return api.PublishRequest{}, fmt.Errorf("failed to decode submit options: %w", err)
+		}
+		if opts.DataShardCount < 0 || opts.ParityShardCount < 0 {
+			return api.PublishRequest{}, fmt.Errorf("shard counts must not be negative: %d %d", opts.DataShardCount, opts.ParityShardCount)
+		}
+		if opts.Protocol != "" {
+			req.Protocol = opts.Protocol
+		}
+		if opts.DataShardCount > 0 {
+			req.DataShardCount = opts.DataShardCount
+		}
+		if opts.ParityShardCount > 0 {
+			req.ParityShardCount = opts.ParityShardCount
+		}
+	}
+
+	// replication targets are IPFS nodes, so a submission to another protocol is not replicated
+	if req.Protocol != "" && req.Protocol != consts.IPFS_PROTOCOL {
+		req.ReplicationTargets = nil
+	}
+	protocol, err := api.PublishProtocol(req.Protocol, req.ReplicationTargets)
+	if err != nil {
+		return api.PublishRequest{}, err
+	}
+	req.Protocol = protocol
+	return req, nil
+}
+
 func (sunrise *SunriseDA) Validate(ctx context.Context, ids []da.ID, daProofs []da.Proof, namespace da.Namespace) ([]bool, error) {
 	var valid []bool
 
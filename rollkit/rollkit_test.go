@@ -0,0 +1,77 @@
+package rollkit
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sunriselayer/sunrise-data/config"
+)
+
+func TestPublishRequest(t *testing.T) {
+	var conf config.Config
+	conf.Rollkit.DataShardCount = 4
+	conf.Rollkit.ParityShardCount = 2
+	conf.Rollkit.ReplicationTargets = []string{"http://127.0.0.1:5002"}
+	conf.Rollkit.ReplicationTimeout = 30
+	sunrise := NewSunriseDA(context.Background(), conf)
+
+	tests := []struct {
+		name              string
+		options           string
+		expectErr         bool
+		protocol          string
+		dataShardCount    int
+		parityShardCount  int
+		replicationTarget bool
+	}{
+		{name: "config defaults to ipfs", protocol: "ipfs", dataShardCount: 4, parityShardCount: 2, replicationTarget: true},
+		{name: "empty options", options: "{}", protocol: "ipfs", dataShardCount: 4, parityShardCount: 2, replicationTarget: true},
+		{name: "shard counts override", options: `{"data_shard_count":8,"parity_shard_count":3}`, protocol: "ipfs", dataShardCount: 8, parityShardCount: 3, replicationTarget: true},
+		{name: "zero counts keep the config", options: `{"data_shard_count":0}`, protocol: "ipfs", dataShardCount: 4, parityShardCount: 2, replicationTarget: true},
+		{name: "protocol override drops ipfs replication", options: `{"protocol":"arweave"}`, protocol: "arweave", dataShardCount: 4, parityShardCount: 2},
+		{name: "unknown field", options: `{"protocol":"ipfs","shards":3}`, expectErr: true},
+		{name: "negative data shard count", options: `{"data_shard_count":-1}`, expectErr: true},
+		{name: "negative parity shard count", options: `{"parity_shard_count":-2}`, expectErr: true},
+		{name: "unsupported protocol", options: `{"protocol":"s3"}`, expectErr: true},
+		{name: "malformed options", options: `{"protocol":`, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options []byte
+			if tt.options != "" {
+				options = []byte(tt.options)
+			}
+			req, err := sunrise.publishRequest(options)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", req)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.Protocol != tt.protocol || req.DataShardCount != tt.dataShardCount || req.ParityShardCount != tt.parityShardCount {
+				t.Fatalf("unexpected request %+v", req)
+			}
+			if replicated := slices.Equal(req.ReplicationTargets, conf.Rollkit.ReplicationTargets); replicated != tt.replicationTarget {
+				t.Fatalf("replication targets %v, expected replication %v", req.ReplicationTargets, tt.replicationTarget)
+			}
+			if req.ReplicationTimeout != conf.Rollkit.ReplicationTimeout {
+				t.Fatalf("replication timeout %d, expected %d", req.ReplicationTimeout, conf.Rollkit.ReplicationTimeout)
+			}
+		})
+	}
+
+	// without replication targets, the protocol of the config is kept
+	conf.Rollkit.Protocol = "arweave"
+	conf.Rollkit.ReplicationTargets = nil
+	req, err := NewSunriseDA(context.Background(), conf).publishRequest(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Protocol != "arweave" || len(req.ReplicationTargets) != 0 {
+		t.Fatalf("unexpected request %+v", req)
+	}
+}
@@ -0,0 +1,59 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sunriselayer/sunrise-data/alert"
+)
+
+// validatorAddresses returns the comma separated validator addresses of ds.
+func validatorAddresses(ds []*duty) string {
+	addresses := []string{}
+	for _, d := range ds {
+		addresses = append(addresses, d.validatorAddress)
+	}
+	return strings.Join(addresses, ",")
+}
+
+func notifyDeadlineMissed(metadataUri string, ds []*duty, reason string, message string) {
+	alert.Notify(alert.Event{
+		Type:     alert.EventDeadlineMissed,
+		Severity: alert.SeverityCritical,
+		Message:  message,
+		Fields: map[string]string{
+			"metadata_uri": metadataUri,
+			"validators":   validatorAddresses(ds),
+			"reason":       reason,
+		},
+	})
+}
+
+func notifyProofFailed(d *duty, metadataUri string, kind failureKind, failures int, err error) {
+	alert.Notify(alert.Event{
+		Type:     alert.EventProofFailed,
+		Severity: alert.SeverityCritical,
+		Message:  fmt.Sprintf("Gave up proving %s for %s after %d failed attempts: %s", metadataUri, d.validatorAddress, failures, err),
+		Fields: map[string]string{
+			"metadata_uri": metadataUri,
+			"validator":    d.validatorAddress,
+			"kind":         string(kind),
+			"failures":     fmt.Sprint(failures),
+			"error":        err.Error(),
+		},
+	})
+}
+
+func notifyInvaliditySubmitted(d *duty, metadataUri string, indices []int64, txHash string) {
+	alert.Notify(alert.Event{
+		Type:     alert.EventInvaliditySubmitted,
+		Severity: alert.SeverityWarning,
+		Message:  fmt.Sprintf("Submitted invalidity of %s for indices %v by %s", metadataUri, indices, d.deputyAddress),
+		Fields: map[string]string{
+			"metadata_uri": metadataUri,
+			"validator":    d.validatorAddress,
+			"indices":      fmt.Sprint(indices),
+			"tx_hash":      txHash,
+		},
+	})
+}
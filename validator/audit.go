@@ -0,0 +1,208 @@
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sunriselayer/sunrise/x/da/erasurecoding"
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/protocols"
+	"github.com/sunriselayer/sunrise-data/utils"
+	"github.com/sunriselayer/sunrise-data/validator/state"
+)
+
+const (
+	// auditFlag records and logs inconsistent data, which is still proved.
+	auditFlag = "flag"
+	// auditReport submits MsgSubmitInvalidity for every shard of inconsistent data in its challenge period,
+	// and does not prove it.
+	auditReport = "report"
+)
+
+// auditedShards are the shards downloaded by the reconstruction audit, whose hashes are reused
+// by the proofs instead of downloading the assigned shards again.
+type auditedShards struct {
+	shardHashes map[int64][]byte
+	failures    map[int64]shardFailure
+}
+
+// fetch returns the audited shards of indices, like fetchShardHashes.
+func (a *auditedShards) fetch(_ protocols.Protocol, _ datypes.Metadata, _ [][]byte, indices []int64) (map[int64][]byte, map[int64]shardFailure) {
+	shardHashes := map[int64][]byte{}
+	failures := map[int64]shardFailure{}
+	for _, index := range indices {
+		if shardHash, ok := a.shardHashes[index]; ok {
+			shardHashes[index] = shardHash
+		} else if failure, ok := a.failures[index]; ok {
+			failures[index] = failure
+		}
+	}
+	return shardHashes, failures
+}
+
+// auditReconstruction reconstructs the blob of data from its valid shards, and returns the
+// inconsistencies between the blob, its metadata and the on-chain record, and whether they are
+// reportable as invalidity. An error is returned when the audit could not be done, e.g. when too
+// few shards could be retrieved. The downloaded shards are returned whenever they were downloaded.
+func auditReconstruction(protocol protocols.Protocol, data datypes.PublishedData, metadata datypes.Metadata) ([]string, bool, *auditedShards, error) {
+	params, err := daParams()
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	problems := []string{}
+	reportable := false
+	report := func(problem string) {
+		problems = append(problems, problem)
+		reportable = true
+	}
+	// publishers did not always record the parity shard count in the metadata, so 0 is not recorded,
+	// and a different count alone is not reported since the shards are checked against the on-chain one
+	if metadata.ParityShardCount != 0 && metadata.ParityShardCount != data.ParityShardCount {
+		problems = append(problems, fmt.Sprintf("metadata parity shard count %d differs from the on-chain %d", metadata.ParityShardCount, data.ParityShardCount))
+	}
+	if metadata.ShardSize > params.MaxShardSize {
+		report(fmt.Sprintf("shard size %d is bigger than the max shard size %d", metadata.ShardSize, params.MaxShardSize))
+	}
+	shardCount := len(metadata.ShardUris)
+	dataShardCount := shardCount - int(data.ParityShardCount)
+	if dataShardCount <= 0 {
+		report(fmt.Sprintf("parity shard count %d leaves no data shard in %d shards", data.ParityShardCount, shardCount))
+		return problems, reportable, nil, nil
+	}
+	if metadata.RecoveredDataSize > metadata.ShardSize*uint64(dataShardCount) {
+		report(fmt.Sprintf("recovered data size %d does not fit in %d data shards of %d bytes", metadata.RecoveredDataSize, dataShardCount, metadata.ShardSize))
+		return problems, reportable, nil, nil
+	}
+
+	// every shard is held until the blob is joined, so the audit is skipped for data larger than max_shard_memory_mib
+	if metadata.ShardSize > uint64(maxShardMemory) || metadata.ShardSize*uint64(shardCount) > uint64(maxShardMemory) {
+		return nil, false, nil, fmt.Errorf("%d shards of %d bytes exceed max_shard_memory_mib", shardCount, metadata.ShardSize)
+	}
+	weight := int64(metadata.ShardSize * uint64(shardCount))
+	if err := shardMemory.Acquire(context.Ctx, weight); err != nil {
+		return nil, false, nil, err
+	}
+	defer shardMemory.Release(weight)
+
+	limit := shardSizeLimit(metadata)
+	var mu sync.Mutex
+	shards := make([][]byte, shardCount)
+	audited := &auditedShards{
+		shardHashes: map[int64][]byte{},
+		failures:    map[int64]shardFailure{},
+	}
+	valid := 0
+	g := new(errgroup.Group)
+	g.SetLimit(fetchWorkers)
+	for i, shardUri := range metadata.ShardUris {
+		g.Go(func() error {
+			shardData, err := protocol.RetrieveLimited(shardUri, limit)
+			if err != nil {
+				mu.Lock()
+				audited.failures[int64(i)] = shardFailure{err: fmt.Errorf("failed to get shard data: %w", err)}
+				mu.Unlock()
+				return nil
+			}
+			shardHash := utils.HashMimc(shardData)
+			shardDoubleHash := utils.HashMimc(shardHash)
+			mu.Lock()
+			defer mu.Unlock()
+			if !bytes.Equal(shardDoubleHash, data.ShardDoubleHashes[i]) {
+				audited.failures[int64(i)] = shardFailure{err: errors.New("incorrect shard double hash"), doubleHash: shardDoubleHash}
+				return nil
+			}
+			audited.shardHashes[int64(i)] = shardHash
+			if uint64(len(shardData)) != metadata.ShardSize {
+				// the shard is the one published, but it cannot be joined with the others
+				report(fmt.Sprintf("shard %d is %d bytes, the metadata shard size is %d", i, len(shardData), metadata.ShardSize))
+				return nil
+			}
+			shards[i] = shardData
+			valid++
+			return nil
+		})
+	}
+	g.Wait()
+	if valid < dataShardCount {
+		return nil, false, audited, fmt.Errorf("only %d valid shards of the expected size, %d are needed to reconstruct", valid, dataShardCount)
+	}
+
+	complete := true
+	for _, shard := range shards[:dataShardCount] {
+		if shard == nil {
+			complete = false
+		}
+	}
+	var blob []byte
+	if complete {
+		blob, err = erasurecoding.JoinShards(shards, dataShardCount, int(metadata.RecoveredDataSize))
+	} else {
+		blob, err = erasurecoding.ReconstructAndJoinShards(shards, dataShardCount, int(metadata.RecoveredDataSize))
+	}
+	if err != nil {
+		report(fmt.Sprintf("failed to reconstruct the data: %s", err))
+		return problems, reportable, audited, nil
+	}
+	if uint64(len(blob)) != metadata.RecoveredDataSize {
+		report(fmt.Sprintf("reconstructed data is %d bytes, the recovered data size is %d", len(blob), metadata.RecoveredDataSize))
+	}
+	hash, err := utils.HashSha256(blob)
+	if err != nil {
+		return nil, false, audited, err
+	}
+	if !bytes.Equal(hash, metadata.RecoveredDataHash) {
+		report("reconstructed data does not match the recovered data hash")
+	}
+	return problems, reportable, audited, nil
+}
+
+// runReconstructionAudit audits data for the validators of ds with reconstruction_audit and records
+// the result in their state. It returns the problems found, whether they are reportable as invalidity,
+// and the shards downloaded by the audit.
+func runReconstructionAudit(protocol protocols.Protocol, data datypes.PublishedData, metadata datypes.Metadata, ds []*duty) ([]string, bool, *auditedShards) {
+	problems, reportable, audited, err := auditReconstruction(protocol, data, metadata)
+	if err != nil {
+		log.Warn().Msgf("Failed to audit the reconstruction of %s: %s", data.MetadataUri, err)
+		return nil, false, audited
+	}
+	audit := &state.Audit{Problems: problems, CheckedAt: time.Now().UTC()}
+	for _, d := range ds {
+		updateRecord(d, data.MetadataUri, func(r *state.Record) {
+			r.Audit = audit
+		})
+	}
+	if len(problems) == 0 {
+		log.Debug().Msgf("Reconstruction of %s matches its metadata", data.MetadataUri)
+		return nil, false, audited
+	}
+
+	inconsistentDataTotal.Inc()
+	log.Warn().Msgf("Inconsistent data %s: %s", data.MetadataUri, strings.Join(problems, "; "))
+	return problems, reportable, audited
+}
+
+// inconsistencyEvidence returns the evidence of every shard of inconsistent data.
+func inconsistencyEvidence(data datypes.PublishedData, metadata datypes.Metadata, problems []string) []state.ShardEvidence {
+	evidence := []state.ShardEvidence{}
+	checkedAt := time.Now().UTC()
+	for i, shardUri := range metadata.ShardUris {
+		evidence = append(evidence, state.ShardEvidence{
+			Index:              int64(i),
+			ShardUri:           shardUri,
+			ExpectedDoubleHash: data.ShardDoubleHashes[i],
+			Error:              "inconsistent data: " + strings.Join(problems, "; "),
+			Attempts:           1,
+			CheckedAt:          checkedAt,
+		})
+	}
+	return evidence
+}
@@ -0,0 +1,206 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/leader"
+)
+
+const (
+	defaultBatchWindow   = 2
+	defaultBatchMaxMsgs  = 10
+	defaultBatchMaxBytes = 500_000
+	defaultBatchMaxGas   = 10_000_000
+)
+
+// errBatchGasLimit is returned when a batch needs more gas than batch_max_gas.
+var errBatchGasLimit = errors.New("batch exceeds batch_max_gas")
+
+// deputySubmission is a message of a deputy, MsgSubmitValidityProof or MsgSubmitInvalidity,
+// waiting to be batched with the other messages of its deputy.
+type deputySubmission struct {
+	deputy *duty
+	msg    sdk.Msg
+	size   int
+	result chan submissionResult
+}
+
+type submissionResult struct {
+	txHash string
+	err    error
+}
+
+var (
+	batchersMu sync.Mutex
+	// batchers are the submissions of the batcher of each deputy address.
+	batchers = map[string]chan deputySubmission{}
+)
+
+// submitDeputyMsg broadcasts msg signed by the deputy of d through the batcher of the deputy, so that
+// the txs of a deputy never race for its account sequence, and waits for the tx that included it.
+func submitDeputyMsg(d *duty, msg sdk.Msg, size int) submissionResult {
+	result := make(chan submissionResult, 1)
+	deputyBatcher(d.deputyAddress) <- deputySubmission{deputy: d, msg: msg, size: size, result: result}
+	return <-result
+}
+
+// deputyBatcher returns the submissions of the batcher of deputy, starting it on first use.
+func deputyBatcher(deputy string) chan<- deputySubmission {
+	batchersMu.Lock()
+	defer batchersMu.Unlock()
+
+	submissions, ok := batchers[deputy]
+	if !ok {
+		submissions = make(chan deputySubmission)
+		batchers[deputy] = submissions
+		go runProofBatcher(submissions)
+	}
+	return submissions
+}
+
+// submitValidityProof sends MsgSubmitValidityProof of the validator of d in a batch with the other
+// proofs of its deputy ready within batch_window, and returns the hash of the tx that included it.
+func submitValidityProof(d *duty, metadataUri string, indices []int64, proofs [][]byte) (string, error) {
+	proofMsg := &datypes.MsgSubmitValidityProof{
+		Sender:           d.deputyAddress,
+		ValidatorAddress: d.validatorAddress,
+		MetadataUri:      metadataUri,
+		Indices:          indices,
+		Proofs:           proofs,
+	}
+	res := submitDeputyMsg(d, proofMsg, proofMsg.Size())
+	if res.err != nil {
+		return "", fmt.Errorf("failed to broadcast MsgSubmitValidityProof transaction: %s %w", metadataUri, res.err)
+	}
+	log.Info().Msgf("MsgSubmitValidityProof TxHash: %s %s %s", metadataUri, d.validatorAddress, res.txHash)
+	return res.txHash, nil
+}
+
+// runProofBatcher gathers the submissions of a deputy within a batch window and broadcasts them in as
+// few txs as batch_max_msgs and batch_max_bytes allow. The txs of the deputy are broadcast one at a
+// time by a single goroutine, which keeps its account sequence in order, while the submissions
+// that arrive meanwhile keep being gathered for the next txs.
+func runProofBatcher(submissions <-chan deputySubmission) {
+	conf := context.Config.Validator
+	window := conf.BatchWindow
+	if window < 0 {
+		window = 0
+	} else if window == 0 {
+		window = defaultBatchWindow
+	}
+	maxMsgs := conf.BatchMaxMsgs
+	if maxMsgs <= 0 {
+		maxMsgs = defaultBatchMaxMsgs
+	}
+	maxBytes := conf.BatchMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultBatchMaxBytes
+	}
+
+	// ready receives the submissions of a closed window once the previous txs are broadcast
+	ready := make(chan []deputySubmission)
+	go func() {
+		for pending := range ready {
+			batch := []deputySubmission{}
+			batchBytes := 0
+			for _, s := range pending {
+				if len(batch) > 0 && (len(batch) >= maxMsgs || batchBytes+s.size > maxBytes) {
+					sendBatch(batch)
+					batch, batchBytes = []deputySubmission{}, 0
+				}
+				batch = append(batch, s)
+				batchBytes += s.size
+			}
+			sendBatch(batch)
+		}
+	}()
+
+	var pending []deputySubmission
+	var windowEnd <-chan time.Time
+	closed := false
+	for {
+		// pending is only handed off once its window is closed
+		var out chan<- []deputySubmission
+		if closed {
+			out = ready
+		}
+		select {
+		case s := <-submissions:
+			if len(pending) == 0 {
+				windowEnd = time.After(time.Duration(window) * time.Second)
+			}
+			pending = append(pending, s)
+		case <-windowEnd:
+			windowEnd = nil
+			closed = true
+		case out <- pending:
+			pending = nil
+			closed = false
+		}
+	}
+}
+
+// sendBatch broadcasts batch, whose submissions have the same deputy, in one tx. If the tx fails, the batch is split in halves
+// which are retried, until the failing submissions are alone in their tx.
+func sendBatch(batch []deputySubmission) {
+	if !leader.IsLeader() {
+		for _, s := range batch {
+			s.result <- submissionResult{err: leader.ErrNotLeader}
+		}
+		return
+	}
+
+	msgs := make([]sdk.Msg, len(batch))
+	for i, s := range batch {
+		msgs[i] = s.msg
+	}
+
+	txHash, err := broadcastBatch(batch[0].deputy, msgs)
+	if err == nil {
+		if len(batch) > 1 {
+			log.Info().Msgf("Batched %d messages in %s", len(batch), txHash)
+		}
+		for _, s := range batch {
+			s.result <- submissionResult{txHash: txHash}
+		}
+		return
+	}
+	if len(batch) == 1 {
+		batch[0].result <- submissionResult{err: err}
+		return
+	}
+
+	log.Warn().Msgf("Failed to broadcast a batch of %d messages, splitting it: %s", len(batch), err)
+	mid := len(batch) / 2
+	sendBatch(batch[:mid])
+	sendBatch(batch[mid:])
+}
+
+func broadcastBatch(d *duty, msgs []sdk.Msg) (string, error) {
+	txService, err := context.NodeClient.CreateTx(context.Ctx, d.deputy, msgs...)
+	if err != nil {
+		return "", err
+	}
+
+	maxGas := context.Config.Validator.BatchMaxGas
+	if maxGas == 0 {
+		maxGas = defaultBatchMaxGas
+	}
+	if len(msgs) > 1 && txService.Gas() > maxGas {
+		return "", fmt.Errorf("%w: %d", errBatchGasLimit, txService.Gas())
+	}
+
+	txResp, err := txService.Broadcast(context.Ctx)
+	if err != nil {
+		return "", err
+	}
+	return txResp.TxHash, nil
+}
@@ -0,0 +1,130 @@
+package validator
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+)
+
+const (
+	defaultNearMissMargin = 60
+
+	// proofDurationWeight is the weight of the last proof in the estimated proof duration.
+	proofDurationWeight = 0.3
+)
+
+// proofDeadline returns the end of the proof period of data. Proofs submitted after it are not counted.
+func proofDeadline(data datypes.PublishedData, params datypes.Params) time.Time {
+	return data.Timestamp.Add(params.ChallengePeriod + params.ProofPeriod)
+}
+
+// proofJob is a challenging data waiting for a data worker.
+type proofJob struct {
+	data     datypes.PublishedData
+	deadline time.Time
+	// notBefore is when the bad shards of the data are rechecked, zero if the job is ready.
+	notBefore time.Time
+}
+
+// proofJobHeap orders jobs by deadline, earliest first.
+type proofJobHeap []proofJob
+
+func (h proofJobHeap) Len() int           { return len(h) }
+func (h proofJobHeap) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+func (h proofJobHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *proofJobHeap) Push(x any)        { *h = append(*h, x.(proofJob)) }
+func (h *proofJobHeap) Pop() any {
+	old := *h
+	job := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return job
+}
+
+// deadlineQueue is a priority queue of proof jobs, closest deadline first.
+type deadlineQueue struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	jobs proofJobHeap
+}
+
+func newDeadlineQueue() *deadlineQueue {
+	q := &deadlineQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *deadlineQueue) push(job proofJob) {
+	q.mu.Lock()
+	heap.Push(&q.jobs, job)
+	q.mu.Unlock()
+	if wait := time.Until(job.notBefore); wait > 0 {
+		// the waiting workers look for a ready job again once it is due
+		time.AfterFunc(wait, q.cond.Broadcast)
+		return
+	}
+	q.cond.Signal()
+}
+
+// pop waits for a ready job and returns the one with the closest deadline.
+func (q *deadlineQueue) pop() proofJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		now := time.Now()
+		ready := -1
+		for i, job := range q.jobs {
+			if !job.notBefore.After(now) && (ready < 0 || job.deadline.Before(q.jobs[ready].deadline)) {
+				ready = i
+			}
+		}
+		if ready >= 0 {
+			return heap.Remove(&q.jobs, ready).(proofJob)
+		}
+		q.cond.Wait()
+	}
+}
+
+// snapshot returns the queued jobs, closest deadline first.
+func (q *deadlineQueue) snapshot() []proofJob {
+	q.mu.Lock()
+	jobs := append([]proofJob{}, q.jobs...)
+	q.mu.Unlock()
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].deadline.Before(jobs[j].deadline)
+	})
+	return jobs
+}
+
+func (q *deadlineQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.jobs.Len()
+}
+
+// durationEstimator is a moving average of the time taken to prove a challenging data.
+type durationEstimator struct {
+	mu       sync.Mutex
+	estimate time.Duration
+}
+
+var proofDurations = &durationEstimator{}
+
+func (e *durationEstimator) observe(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.estimate == 0 {
+		e.estimate = d
+		return
+	}
+	e.estimate = time.Duration(proofDurationWeight*float64(d) + (1-proofDurationWeight)*float64(e.estimate))
+}
+
+// get returns the estimated duration, or 0 before any proof.
+func (e *durationEstimator) get() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.estimate
+}
@@ -0,0 +1,139 @@
+package validator
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/cosmosclient/cosmosaccount"
+)
+
+// OperatorAccount returns the keyring account of the validator operator.
+// If name is empty, the account is looked up by the address of validatorAddress.
+func OperatorAccount(validatorAddress string, name string) (cosmosaccount.Account, error) {
+	if name != "" {
+		return context.NodeClient.Account(name)
+	}
+	valAddr, err := sdk.ValAddressFromBech32(validatorAddress)
+	if err != nil {
+		return cosmosaccount.Account{}, fmt.Errorf("failed to parse validator address %s: %w", validatorAddress, err)
+	}
+	return context.NodeClient.Account(sdk.AccAddress(valAddr).String())
+}
+
+// duty is a validator proven by this process and the deputy account that submits its proofs.
+type duty struct {
+	validatorAddress string
+	validator        sdk.ValAddress
+	deputy           cosmosaccount.Account
+	deputyAddress    string
+}
+
+// duties are the validators proven by this process, in the order of the config.
+var duties []*duty
+
+// loadDuties loads the validators of the config, and with loadDeputies the deputy account
+// of each validator from the keyring.
+func loadDuties(loadDeputies bool) error {
+	loaded := []*duty{}
+	seen := map[string]bool{}
+	for _, pair := range context.Config.ValidatorPairs() {
+		if seen[pair.ValidatorAddress] {
+			return fmt.Errorf("validator %s is configured twice", pair.ValidatorAddress)
+		}
+		seen[pair.ValidatorAddress] = true
+
+		validator, err := sdk.ValAddressFromBech32(pair.ValidatorAddress)
+		if err != nil {
+			return fmt.Errorf("failed to parse validator address %s: %w", pair.ValidatorAddress, err)
+		}
+		d := &duty{
+			validatorAddress: pair.ValidatorAddress,
+			validator:        validator,
+		}
+		if loadDeputies {
+			d.deputy, err = context.NodeClient.Account(pair.ProofDeputyAccount)
+			if err != nil {
+				return fmt.Errorf("failed to get proof deputy account %s of %s: %w", pair.ProofDeputyAccount, pair.ValidatorAddress, err)
+			}
+			d.deputyAddress, err = d.deputy.Address(context.Config.Chain.AddressPrefix)
+			if err != nil {
+				return err
+			}
+		}
+		loaded = append(loaded, d)
+	}
+	if len(loaded) == 0 {
+		return fmt.Errorf("validator_address is not configured")
+	}
+	duties = loaded
+	return nil
+}
+
+// DeputyAddress returns the address of a proof_deputy_account, which may be a key name or an address.
+func DeputyAddress(deputy string) (string, error) {
+	account, err := context.NodeClient.Account(deputy)
+	if err == nil {
+		return account.Address(context.Config.Chain.AddressPrefix)
+	}
+	if _, addrErr := sdk.AccAddressFromBech32(deputy); addrErr == nil {
+		return deputy, nil
+	}
+	return "", fmt.Errorf("proof_deputy_account %s is neither a key nor an address: %w", deputy, err)
+}
+
+// OnchainDeputy returns the deputy registered on-chain for validatorAddress.
+func OnchainDeputy(validatorAddress string) (string, error) {
+	res, err := context.QueryClient.ProofDeputy(context.Ctx, &datypes.QueryProofDeputyRequest{ValidatorAddress: validatorAddress})
+	if err != nil {
+		return "", err
+	}
+	return res.DeputyAddress, nil
+}
+
+// CheckDeputy returns an error unless deputyAddress is the on-chain deputy of validatorAddress.
+func CheckDeputy(validatorAddress string, deputyAddress string) error {
+	onchain, err := OnchainDeputy(validatorAddress)
+	if err != nil {
+		return fmt.Errorf("no proof deputy is registered for %s: %w", validatorAddress, err)
+	}
+	if onchain != deputyAddress {
+		return fmt.Errorf("%s is not registered as a proof deputy of %s, the registered deputy is %s", deputyAddress, validatorAddress, onchain)
+	}
+	return nil
+}
+
+// RegisterProofDeputy signs MsgRegisterProofDeputy with the validator operator account.
+func RegisterProofDeputy(operator cosmosaccount.Account, deputyAddress string) (string, error) {
+	sender, err := operator.Address(context.Config.Chain.AddressPrefix)
+	if err != nil {
+		return "", err
+	}
+	msg := &datypes.MsgRegisterProofDeputy{
+		Sender:        sender,
+		DeputyAddress: deputyAddress,
+	}
+	txResp, err := context.NodeClient.BroadcastTx(context.Ctx, operator, msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast MsgRegisterProofDeputy transaction: %w", err)
+	}
+	return txResp.TxHash, nil
+}
+
+// UnregisterProofDeputy signs MsgUnregisterProofDeputy with the validator operator account.
+func UnregisterProofDeputy(operator cosmosaccount.Account) (string, error) {
+	sender, err := operator.Address(context.Config.Chain.AddressPrefix)
+	if err != nil {
+		return "", err
+	}
+	msg := &datypes.MsgUnregisterProofDeputy{
+		Sender: sender,
+	}
+	txResp, err := context.NodeClient.BroadcastTx(context.Ctx, operator, msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast MsgUnregisterProofDeputy transaction: %w", err)
+	}
+	return txResp.TxHash, nil
+}
@@ -0,0 +1,244 @@
+package validator
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/rs/zerolog/log"
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/cosmosclient"
+)
+
+const (
+	// metadataUriAttribute is the event attribute set by the DA module for
+	// MsgPublishData and for every status change of a published data.
+	metadataUriAttribute = "metadata_uri"
+
+	newBlockSubscriber = "sunrise-data-validator"
+	newBlockQuery      = "tm.event='NewBlock'"
+
+	defaultCatchUpBlocks     = 1000
+	defaultReconcileInterval = 300
+	defaultSweepPageSize     = 100
+
+	// maxBlockAttempts is the number of times a block is processed before it is skipped,
+	// e.g. when it was pruned from the node. Its data is found by the reconciliation sweep.
+	maxBlockAttempts = 3
+)
+
+var (
+	// lastProcessedHeight is the last block height whose events have been processed.
+	lastProcessedHeight atomic.Int64
+	// lastFollowedAt is the unix time when the latest block height was last queried.
+	lastFollowedAt atomic.Int64
+
+	// failedHeight is the height that failed blockAttempts times in a row, only used by followBlocks.
+	failedHeight  int64
+	blockAttempts int
+)
+
+// catchUpBlocks returns the number of recent blocks scanned when following starts.
+func catchUpBlocks() int64 {
+	if blocks := context.Config.Validator.CatchUpBlocks; blocks > 0 {
+		return blocks
+	}
+	return defaultCatchUpBlocks
+}
+
+// catchUp processes the MsgPublishData txs of the heights missed while the validator was not running.
+func catchUp(fromHeight int64) {
+	log.Info().Msgf("Catching up published data from height %d", fromHeight)
+	tc := make(chan []cosmosclient.TX)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- context.NodeClient.CollectTXs(context.Ctx, fromHeight, tc)
+	}()
+
+	count := 0
+	for txs := range tc {
+		for _, tx := range txs {
+			for _, uri := range metadataUrisFromEvents(tx.Raw.TxResult.Events) {
+				refreshPublishedData(uri)
+				count++
+			}
+		}
+	}
+	if err := <-errCh; err != nil {
+		log.Error().Msgf("Failed to catch up txs from height %d, the reconciliation sweep will cover them: %s", fromHeight, err)
+		return
+	}
+	log.Info().Msgf("Caught up %d published data events", count)
+}
+
+// followBlocks processes the events of every new block. New blocks are notified by a
+// CometBFT subscription, and heights are also polled in case the subscription drops.
+func followBlocks() {
+	var newBlocks <-chan ctypes.ResultEvent
+	if err := context.NodeClient.RPC.Start(); err != nil {
+		log.Warn().Msgf("Failed to start event subscription, polling new blocks instead: %s", err)
+	} else if newBlocks, err = context.NodeClient.RPC.Subscribe(context.Ctx, newBlockSubscriber, newBlockQuery); err != nil {
+		log.Warn().Msgf("Failed to subscribe to new blocks, polling new blocks instead: %s", err)
+		newBlocks = nil
+	}
+
+	ticker := time.NewTicker(time.Duration(context.Config.Validator.ProofInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case _, ok := <-newBlocks:
+			if !ok {
+				log.Warn().Msg("New block subscription closed, polling new blocks instead")
+				newBlocks = nil
+				continue
+			}
+		case <-ticker.C:
+		}
+		processNewBlocks()
+	}
+}
+
+func processNewBlocks() {
+	latestHeight, err := context.NodeClient.LatestBlockHeight(context.Ctx)
+	if err != nil {
+		log.Error().Msgf("Failed to query latest block height: %s", err)
+		return
+	}
+	lastFollowedAt.Store(time.Now().Unix())
+	startHeight := lastProcessedHeight.Load()
+	if startHeight == 0 {
+		// the latest height could not be queried when following started
+		startHeight = max(latestHeight-catchUpBlocks(), savedHeight(), 0)
+		lastProcessedHeight.Store(startHeight)
+	}
+	defer func() {
+		if height := lastProcessedHeight.Load(); height != startHeight {
+			saveHeight(height)
+		}
+	}()
+	for height := startHeight + 1; height <= latestHeight; height++ {
+		if err := processBlock(height); err != nil {
+			if height != failedHeight {
+				failedHeight, blockAttempts = height, 0
+			}
+			blockAttempts++
+			if blockAttempts < maxBlockAttempts {
+				log.Error().Msgf("Failed to process block %d: %s", height, err)
+				return
+			}
+			log.Warn().Msgf("Skipping block %d after %d failed attempts, the reconciliation sweep will cover it: %s", height, blockAttempts, err)
+		}
+		lastProcessedHeight.Store(height)
+	}
+}
+
+// processBlock refreshes every published data referred to by the tx and finalize block events of height.
+func processBlock(height int64) error {
+	res, err := context.NodeClient.RPC.BlockResults(context.Ctx, &height)
+	if err != nil {
+		return err
+	}
+
+	events := res.FinalizeBlockEvents
+	for _, txResult := range res.TxsResults {
+		events = append(events, txResult.Events...)
+	}
+	for _, uri := range metadataUrisFromEvents(events) {
+		refreshPublishedData(uri)
+	}
+	return nil
+}
+
+// metadataUrisFromEvents returns the distinct metadata URIs found in events.
+func metadataUrisFromEvents(events []abci.Event) []string {
+	seen := map[string]bool{}
+	uris := []string{}
+	for _, event := range events {
+		for _, attr := range event.Attributes {
+			if attr.Key != metadataUriAttribute {
+				continue
+			}
+			// typed events carry JSON encoded values
+			uri := strings.Trim(attr.Value, `"`)
+			if uri == "" || seen[uri] {
+				continue
+			}
+			seen[uri] = true
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// refreshPublishedData queries the current state of a published data and updates the tracked challenges.
+func refreshPublishedData(metadataUri string) {
+	res, err := context.QueryClient.PublishedData(context.Ctx, &datypes.QueryPublishedDataRequest{MetadataUri: metadataUri})
+	if err != nil {
+		// removed data is no longer queryable
+		log.Debug().Msgf("Failed to query published data %s: %s", metadataUri, err)
+		return
+	}
+	if challenges.update(res.Data) {
+		log.Info().Msgf("Detected new challenging data: %s", metadataUri)
+	}
+	switch {
+	case res.Data.Status == datypes.Status_STATUS_CHALLENGE_PERIOD:
+		scheduleInvalidityCheck(res.Data)
+		schedulePrefetch(res.Data)
+	case pinReleasable(res.Data.Status):
+		releasePins(metadataUri)
+	}
+	if res.Data.Status != datypes.Status_STATUS_CHALLENGE_PERIOD {
+		invalidityChecked.Delete(metadataUri)
+	}
+}
+
+// reconcile sweeps every published data page by page, as a safety net for missed events.
+func reconcile() {
+	pageSize := context.Config.Validator.SweepPageSize
+	if pageSize == 0 {
+		pageSize = defaultSweepPageSize
+	}
+
+	challenging := map[string]datypes.PublishedData{}
+	inPeriod := map[string]bool{}
+	active := map[string]bool{}
+	var nextKey []byte
+	for {
+		res, err := context.QueryClient.AllPublishedData(context.Ctx, &datypes.QueryAllPublishedDataRequest{
+			Pagination: &query.PageRequest{Key: nextKey, Limit: pageSize},
+		})
+		if err != nil {
+			log.Error().Msgf("Failed to query all-published-data from on-chain: %s", err)
+			return
+		}
+		for _, data := range res.Data {
+			switch data.Status {
+			case datypes.Status_STATUS_CHALLENGING:
+				challenging[data.MetadataUri] = data
+				active[data.MetadataUri] = true
+			case datypes.Status_STATUS_CHALLENGE_PERIOD:
+				inPeriod[data.MetadataUri] = true
+				active[data.MetadataUri] = true
+				// the events of data published while the validator was not following are missed
+				scheduleInvalidityCheck(data)
+				schedulePrefetch(data)
+			}
+		}
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			break
+		}
+		nextKey = res.Pagination.NextKey
+	}
+
+	added, removed := challenges.replace(challenging)
+	log.Debug().Msgf("Reconciled challenging data: %d tracked, %d added, %d removed", len(challenging), added, removed)
+	forgetInvalidityChecks(inPeriod)
+	sweepPins()
+	pruneRecords(active)
+}
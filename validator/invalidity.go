@@ -0,0 +1,338 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/leader"
+	"github.com/sunriselayer/sunrise-data/protocols"
+	"github.com/sunriselayer/sunrise-data/validator/state"
+)
+
+const (
+	defaultInvalidityRetries       = 3
+	defaultInvalidityRetryInterval = 20
+
+	// maxInvalidityQueue is the number of data in challenge period waiting to be checked before new ones are dropped.
+	maxInvalidityQueue = 1000
+)
+
+// shardRecheck keeps the shards of a data between the rechecks of its bad shards.
+type shardRecheck struct {
+	retries     int
+	shardHashes map[int64][]byte
+	failures    map[int64]shardFailure
+	attempts    map[int64]int
+	checkedAt   map[int64]time.Time
+}
+
+var (
+	// invalidityQueue feeds the data in challenge period to the invalidity check worker.
+	invalidityQueue chan datypes.PublishedData
+	// invalidityChecking holds the metadata URIs queued or being checked.
+	invalidityChecking sync.Map
+	// invalidityChecked holds the metadata URIs in their challenge period whose check is over,
+	// so that the reconciliation sweep and later events do not check them again.
+	invalidityChecked sync.Map
+
+	// proofRechecks holds the pending shard rechecks of challenging data by metadata URI.
+	proofRechecks sync.Map
+	// invalidityRechecks holds the pending shard rechecks of data in challenge period by metadata URI.
+	invalidityRechecks sync.Map
+)
+
+// checkShards downloads the shards of indices with fetch and returns the hash of each valid shard by index,
+// with the evidence of the shards that stay bad. Bad shards are retried invalidity_retries times
+// every invalidity_retry_interval, since a shard may only be temporarily unavailable. Instead of
+// waiting, checkShards keeps the shards in rechecks and returns the time of the next recheck, when
+// it is to be called again, and only the bad shards are downloaded then.
+func checkShards(rechecks *sync.Map, metadataUri string, protocol protocols.Protocol, metadata datypes.Metadata, doubleHashes [][]byte, indices []int64, fetch shardFetcher) (map[int64][]byte, []state.ShardEvidence, time.Time) {
+	retries := context.Config.Validator.InvalidityRetries
+	if retries <= 0 {
+		retries = defaultInvalidityRetries
+	}
+	interval := context.Config.Validator.InvalidityRetryInterval
+	if interval <= 0 {
+		interval = defaultInvalidityRetryInterval
+	}
+
+	var r *shardRecheck
+	if value, ok := rechecks.Load(metadataUri); ok {
+		r = value.(*shardRecheck)
+		badIndices := []int64{}
+		for index := range r.failures {
+			badIndices = append(badIndices, index)
+		}
+		recovered, stillBad := fetchShardHashes(protocol, metadata, doubleHashes, badIndices)
+		for index, shardHash := range recovered {
+			log.Info().Msgf("Shard %d recovered after %d attempts", index, r.attempts[index])
+			r.shardHashes[index] = shardHash
+		}
+		for _, index := range badIndices {
+			r.attempts[index]++
+			r.checkedAt[index] = time.Now().UTC()
+		}
+		r.failures = stillBad
+		r.retries++
+	} else {
+		shardHashes, failures := fetch(protocol, metadata, doubleHashes, indices)
+		if len(failures) == 0 {
+			return shardHashes, nil, time.Time{}
+		}
+		r = &shardRecheck{
+			shardHashes: shardHashes,
+			failures:    failures,
+			attempts:    map[int64]int{},
+			checkedAt:   map[int64]time.Time{},
+		}
+		for index := range failures {
+			r.attempts[index] = 1
+			r.checkedAt[index] = time.Now().UTC()
+		}
+	}
+
+	if len(r.failures) > 0 && r.retries < retries {
+		rechecks.Store(metadataUri, r)
+		log.Warn().Msgf("%d bad shards of %s, retrying in %v sec", len(r.failures), metadataUri, interval)
+		return nil, nil, time.Now().Add(time.Duration(interval) * time.Second)
+	}
+	rechecks.Delete(metadataUri)
+
+	evidence := []state.ShardEvidence{}
+	for index, failure := range r.failures {
+		evidence = append(evidence, state.ShardEvidence{
+			Index:              index,
+			ShardUri:           metadata.ShardUris[index],
+			ExpectedDoubleHash: doubleHashes[index],
+			ActualDoubleHash:   failure.doubleHash,
+			Error:              failure.err.Error(),
+			Attempts:           r.attempts[index],
+			CheckedAt:          r.checkedAt[index],
+		})
+	}
+	sort.Slice(evidence, func(i, j int) bool {
+		return evidence[i].Index < evidence[j].Index
+	})
+	return r.shardHashes, evidence, time.Time{}
+}
+
+// recheckPending tells if the bad shards of metadataUri are waiting for a recheck in rechecks.
+func recheckPending(rechecks *sync.Map, metadataUri string) bool {
+	_, ok := rechecks.Load(metadataUri)
+	return ok
+}
+
+// startInvalidityChecks starts the worker that checks the shards of data in its challenge period,
+// when MsgSubmitInvalidity is accepted, and reports the shards that stay bad.
+func startInvalidityChecks() {
+	invalidityQueue = make(chan datypes.PublishedData, maxInvalidityQueue)
+	go func() {
+		for data := range invalidityQueue {
+			recheckAt, err := checkInvalidity(data)
+			if err != nil {
+				log.Warn().Msgf("Failed to check the shards of %s: %s", data.MetadataUri, err)
+			}
+			if !recheckAt.IsZero() {
+				// the data stays scheduled, and the worker checks other data until its bad shards are rechecked
+				time.AfterFunc(time.Until(recheckAt), func() {
+					invalidityQueue <- data
+				})
+				continue
+			}
+			invalidityChecking.Delete(data.MetadataUri)
+		}
+	}()
+}
+
+// scheduleInvalidityCheck queues data in its challenge period to be checked, unless it is already queued or checked.
+func scheduleInvalidityCheck(data datypes.PublishedData) {
+	if invalidityQueue == nil {
+		return
+	}
+	if _, checked := invalidityChecked.Load(data.MetadataUri); checked {
+		return
+	}
+	if _, loaded := invalidityChecking.LoadOrStore(data.MetadataUri, struct{}{}); loaded {
+		return
+	}
+	select {
+	case invalidityQueue <- data:
+	default:
+		invalidityChecking.Delete(data.MetadataUri)
+		log.Warn().Msgf("Invalidity check queue is full, %s is not checked", data.MetadataUri)
+	}
+}
+
+// checkInvalidity checks the shards assigned to the validators of data, or every shard with full_audit,
+// and reports the shards that stay bad, or every shard of inconsistent data with reconstruction_audit
+// set to report. It returns when the bad shards are to be rechecked, zero otherwise.
+func checkInvalidity(data datypes.PublishedData) (time.Time, error) {
+	// the status may have changed while the data was queued
+	res, err := context.QueryClient.PublishedData(context.Ctx, &datypes.QueryPublishedDataRequest{MetadataUri: data.MetadataUri})
+	if err != nil || res.Data.Status != datypes.Status_STATUS_CHALLENGE_PERIOD || !leader.IsLeader() {
+		invalidityRechecks.Delete(data.MetadataUri)
+		return time.Time{}, nil
+	}
+	data = res.Data
+
+	protocol, metadata, err := retrieveMetadata(data)
+	if err != nil {
+		invalidityRechecks.Delete(data.MetadataUri)
+		return time.Time{}, err
+	}
+	fetch := fetchShardHashes
+	if context.Config.Validator.ReconstructionAudit == auditReport && !recheckPending(&invalidityRechecks, data.MetadataUri) {
+		problems, reportable, audited := runReconstructionAudit(protocol, data, metadata, duties)
+		if reportable {
+			reported := true
+			for i, err := range reportInvalidity(duties, data.MetadataUri, inconsistencyEvidence(data, metadata, problems)) {
+				if err != nil {
+					log.Error().Msgf("Failed to report inconsistent data %s for %s: %s", data.MetadataUri, duties[i].validatorAddress, err)
+					reported = false
+					continue
+				}
+				recordState(duties[i], data.MetadataUri, state.StateInvaliditySubmitted, "inconsistent data", nil)
+			}
+			if reported {
+				invalidityChecked.Store(data.MetadataUri, struct{}{})
+			}
+			return time.Time{}, nil
+		}
+		if audited != nil {
+			fetch = audited.fetch
+		}
+	}
+
+	indices := allIndices(len(metadata.ShardUris))
+	if !context.Config.Validator.FullAudit {
+		if indices, err = assignedIndices(len(metadata.ShardUris)); err != nil {
+			invalidityRechecks.Delete(data.MetadataUri)
+			return time.Time{}, err
+		}
+	}
+	_, evidence, recheckAt := checkShards(&invalidityRechecks, data.MetadataUri, protocol, metadata, data.ShardDoubleHashes, indices, fetch)
+	if !recheckAt.IsZero() {
+		return recheckAt, nil
+	}
+	if len(evidence) == 0 {
+		invalidityChecked.Store(data.MetadataUri, struct{}{})
+		return time.Time{}, nil
+	}
+	// a failed report is retried by the next check
+	reported := true
+	for i, err := range reportInvalidity(duties, data.MetadataUri, evidence) {
+		if err != nil {
+			log.Error().Msgf("Failed to report invalidity of %s for %s: %s", data.MetadataUri, duties[i].validatorAddress, err)
+			reported = false
+		}
+	}
+	if reported {
+		invalidityChecked.Store(data.MetadataUri, struct{}{})
+	}
+	return time.Time{}, nil
+}
+
+// forgetInvalidityChecks forgets the checked metadata URIs that are no longer in inPeriod,
+// the metadata URIs in their challenge period.
+func forgetInvalidityChecks(inPeriod map[string]bool) {
+	invalidityChecked.Range(func(key, _ any) bool {
+		if !inPeriod[key.(string)] {
+			invalidityChecked.Delete(key)
+		}
+		return true
+	})
+}
+
+// assignedIndices returns the shard indices assigned to any of the validators, in ascending order.
+func assignedIndices(shardCount int) ([]int64, error) {
+	res, err := context.QueryClient.ZkpProofThreshold(context.Ctx, &datypes.QueryZkpProofThresholdRequest{ShardCount: uint64(shardCount)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Threshold: %w", err)
+	}
+	assigned := make([][]int64, len(duties))
+	for i, d := range duties {
+		assigned[i] = datypes.ShardIndicesForValidator(d.validator, int64(res.Threshold), int64(shardCount))
+	}
+	indices := []int64{}
+	for _, index := range unionIndices(assigned) {
+		if index >= 0 && index < int64(shardCount) {
+			indices = append(indices, index)
+		}
+	}
+	return indices, nil
+}
+
+// reportInvalidity broadcasts MsgSubmitInvalidity for the shards in evidence once per deputy of ds,
+// since invalidity is kept by sender, and records the decision with its evidence in the state of
+// each validator. It returns the error of each validator in the order of ds.
+func reportInvalidity(ds []*duty, metadataUri string, evidence []state.ShardEvidence) []error {
+	type report struct {
+		invalidity *state.Invalidity
+		err        error
+	}
+	reports := map[string]report{}
+	errs := make([]error, len(ds))
+	for i, d := range ds {
+		r, ok := reports[d.deputyAddress]
+		if !ok {
+			r.invalidity, r.err = submitDeputyInvalidity(d, metadataUri, evidence)
+			reports[d.deputyAddress] = r
+		}
+		if r.invalidity != nil {
+			updateRecord(d, metadataUri, func(record *state.Record) {
+				record.Invalidity = r.invalidity
+			})
+		}
+		errs[i] = r.err
+	}
+	return errs
+}
+
+// submitDeputyInvalidity broadcasts MsgSubmitInvalidity of the deputy of d for the shards in evidence, unless
+// it was already submitted or the data is no longer in its challenge period. It returns the invalidity to record,
+// nil if nothing was broadcast.
+func submitDeputyInvalidity(d *duty, metadataUri string, evidence []state.ShardEvidence) (*state.Invalidity, error) {
+	if record, found, _ := getRecord(d, metadataUri); found && record.Invalidity != nil && record.Invalidity.TxHash != "" {
+		log.Info().Msgf("Invalidity of %s was already submitted: %s", metadataUri, record.Invalidity.TxHash)
+		return nil, nil
+	}
+	if _, err := context.QueryClient.Invalidity(context.Ctx, &datypes.QueryInvalidityRequest{MetadataUri: metadataUri, SenderAddress: d.deputyAddress}); err == nil {
+		log.Info().Msgf("Invalidity of %s is already on-chain", metadataUri)
+		return nil, nil
+	}
+	res, err := context.QueryClient.PublishedData(context.Ctx, &datypes.QueryPublishedDataRequest{MetadataUri: metadataUri})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query published data: %w", err)
+	}
+	if res.Data.Status != datypes.Status_STATUS_CHALLENGE_PERIOD {
+		return nil, fmt.Errorf("%s is %s, invalidity is only accepted in its challenge period", metadataUri, res.Data.Status)
+	}
+
+	invalidity := &state.Invalidity{
+		Evidence:  evidence,
+		DecidedAt: time.Now().UTC(),
+	}
+	for _, e := range evidence {
+		invalidity.Indices = append(invalidity.Indices, e.Index)
+	}
+	log.Warn().Msgf("Submitting invalidity of %s for indices %v by %s", metadataUri, invalidity.Indices, d.deputyAddress)
+
+	txHash, err := submitInvalidity(d, metadataUri, invalidity.Indices)
+	if errors.Is(err, leader.ErrNotLeader) {
+		return nil, err
+	}
+	invalidity.TxHash = txHash
+	if err != nil {
+		invalidity.Error = err.Error()
+		return invalidity, fmt.Errorf("failed to submit invalidity: %w", err)
+	}
+	notifyInvaliditySubmitted(d, metadataUri, invalidity.Indices, txHash)
+	return invalidity, nil
+}
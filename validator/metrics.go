@@ -0,0 +1,72 @@
+package validator
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "sunrise_data_validator"
+
+var (
+	deadlineMissedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deadline_missed_total",
+		Help:      "Challenging data whose proof period ended before a proof was submitted, by reason (skipped, late).",
+	}, []string{"reason"})
+
+	deadlineNearMissTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deadline_near_miss_total",
+		Help:      "Proofs submitted with less than near_miss_margin left in the proof period.",
+	})
+
+	proofTimeLeftSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "proof_time_left_seconds",
+		Help:      "Time left in the proof period when a proof was submitted.",
+		Buckets:   []float64{30, 60, 120, 300, 600, 1800, 3600, 7200, 21600, 86400},
+	})
+
+	proofVerificationFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "proof_verification_failures_total",
+		Help:      "Generated proofs that failed the local groth16 verification and were not submitted.",
+	})
+
+	proofsSubmittedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "proofs_submitted_total",
+		Help:      "MsgSubmitValidityProof included in a tx, by validator.",
+	}, []string{"validator"})
+
+	proofFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "proof_failures_total",
+		Help:      "Failed proof attempts, by validator and failure kind.",
+	}, []string{"validator", "kind"})
+
+	inconsistentDataTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "inconsistent_data_total",
+		Help:      "Data whose reconstruction did not match its metadata or its on-chain record.",
+	})
+
+	pinnedData = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "pinned_data",
+		Help:      "Published data whose pre-fetched shards are pinned.",
+	})
+
+	prefetchedShardsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "prefetched_shards_total",
+		Help:      "Shards pinned as soon as their data was published.",
+	})
+
+	proofDurationSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "proof_duration_seconds",
+		Help:      "Time taken to fetch shards, generate proofs and submit them for a challenging data.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+)
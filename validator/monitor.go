@@ -1,21 +1,32 @@
 package validator
 
 import (
-	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
-	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog/log"
 	datypes "github.com/sunriselayer/sunrise/x/da/types"
 
 	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/leader"
 	"github.com/sunriselayer/sunrise-data/protocols"
-	"github.com/sunriselayer/sunrise-data/utils"
+	"github.com/sunriselayer/sunrise-data/validator/state"
 )
 
 func Monitor() {
-	log.Info().Msgf("On-chain data is checked every %v sec", context.Config.Validator.ProofInterval)
+	// shards of the data published during the catch up are pre-fetched within the proof limits
+	startProofWorkers()
+	startInvalidityChecks()
+	startPrefetching()
+	startFollowingChallenges()
+	if context.Config.Validator.StatusPort > 0 {
+		go serveStatus(context.Config.Validator.StatusPort)
+	}
+
+	log.Info().Msgf("Challenging data is checked every %v sec", context.Config.Validator.ProofInterval)
 	ticker := time.NewTicker(time.Duration(context.Config.Validator.ProofInterval) * time.Second)
 	quit := make(chan struct{})
 	go func() {
@@ -31,124 +42,424 @@ func Monitor() {
 	}()
 }
 
+// startFollowingChallenges catches up on missed heights, then follows new blocks
+// and runs a periodic reconciliation sweep to keep the tracked challenges up to date.
+func startFollowingChallenges() {
+	latestHeight, err := context.NodeClient.LatestBlockHeight(context.Ctx)
+	if err != nil {
+		log.Error().Msgf("Failed to query latest block height: %s", err)
+	} else {
+		fromHeight := max(latestHeight-catchUpBlocks()+1, 1)
+		// resume from the height saved by the previous run, but scan catch_up_blocks at most,
+		// since the reconciliation sweep covers the data published before them
+		if saved := savedHeight(); saved > 0 && saved < latestHeight {
+			fromHeight = max(saved+1, fromHeight)
+		}
+		if fromHeight <= latestHeight {
+			catchUp(fromHeight)
+		}
+		lastProcessedHeight.Store(latestHeight)
+		lastFollowedAt.Store(time.Now().Unix())
+		saveHeight(latestHeight)
+	}
+	reconcile()
+	log.Info().Msgf("Tracking %d challenging data", challenges.len())
+
+	go followBlocks()
+
+	reconcileInterval := context.Config.Validator.ReconcileInterval
+	if reconcileInterval <= 0 {
+		reconcileInterval = defaultReconcileInterval
+	}
+	log.Info().Msgf("On-chain data is reconciled every %v sec", reconcileInterval)
+	go func() {
+		ticker := time.NewTicker(time.Duration(reconcileInterval) * time.Second)
+		for range ticker.C {
+			reconcile()
+		}
+	}()
+}
+
 func MonitorChallengingData() {
-	validatorAddress := context.Config.Validator.ValidatorAddress
-	if validatorAddress == "" {
-		log.Error().Msg("validator_address is empty in config.toml")
+	if !leader.IsLeader() {
+		log.Debug().Msg("Standing by, challenging data is proved by the leader")
 		return
 	}
-	log.Debug().Msgf("Fetching published data from sunrised...")
-	allPublishedDataResponse, err := context.QueryClient.AllPublishedData(context.Ctx, &datypes.QueryAllPublishedDataRequest{})
+	params, err := daParams()
 	if err != nil {
-		log.Error().Msgf("Failed to query all-published-data from on-chain: %s", err)
+		log.Error().Msgf("Failed to get params: %s", err)
 		return
 	}
-	allData := allPublishedDataResponse.Data
-	for _, data := range allData {
-		if data.Status == datypes.Status_STATUS_CHALLENGING {
-			_, err := context.QueryClient.ValidityProof(context.Ctx, &datypes.QueryValidityProofRequest{MetadataUri: data.MetadataUri, ValidatorAddress: validatorAddress})
-			if err != nil {
-				log.Info().Msgf("Detected new challenging data: %s", data.MetadataUri)
-				SubmitProofTx(data)
-			}
+	for _, data := range challenges.pending() {
+		if len(readyDuties(data.MetadataUri)) == 0 {
+			continue
 		}
+		enqueueProof(data, proofDeadline(data, params))
 	}
-	log.Debug().Msgf("Finished monitoring challenging data")
+	log.Debug().Msgf("Finished monitoring challenging data, %d queued", proofQueue.len())
 }
 
-func SubmitProofTx(data datypes.PublishedData) bool {
-	peerAddrInfo, err := peer.AddrInfoFromString(data.DataSourceInfo)
-	if err == nil {
-		protocols.ConnectSwarm(*peerAddrInfo)
+// readyDuties returns the validators whose proof of metadataUri can be attempted now.
+func readyDuties(metadataUri string) []*duty {
+	ready := []*duty{}
+	for _, d := range duties {
+		if readyForAttempt(d, metadataUri) {
+			ready = append(ready, d)
+		}
 	}
-	protocol, err := protocols.GetRetrieveProtocol(data.MetadataUri)
-	if err != nil {
-		log.Error().Msgf("Failed to get protocol: %s", err)
-		return false
+	return ready
+}
+
+// proveChallenge proves the data of job for the validators that have not proven it yet,
+// unless the proof can no longer be submitted before the deadline. It returns when the data
+// is to be proved again if its bad shards are rechecked, zero otherwise.
+func proveChallenge(job proofJob) time.Time {
+	data := job.data
+	if !leader.IsLeader() {
+		// the new leader proves it
+		proofRechecks.Delete(data.MetadataUri)
+		return time.Time{}
+	}
+	pending := []*duty{}
+	for _, d := range duties {
+		if needsProof(d, data.MetadataUri) {
+			pending = append(pending, d)
+		}
+	}
+	if len(pending) == 0 {
+		challenges.markDone(data.MetadataUri)
+		return time.Time{}
 	}
 
-	// verify shard data
-	metadataBytes, err := protocol.Retrieve(data.MetadataUri)
+	timeLeft := time.Until(job.deadline)
+	if estimate := proofDurations.get(); timeLeft <= estimate {
+		log.Warn().Msgf("Skipping %s: %v left until the proof deadline, proving takes about %v", data.MetadataUri, timeLeft.Round(time.Second), estimate.Round(time.Second))
+		deadlineMissedTotal.WithLabelValues("skipped").Inc()
+		for _, d := range pending {
+			recordState(d, data.MetadataUri, state.StateDeadlineMissed, fmt.Sprintf("skipped with %v left", timeLeft.Round(time.Second)), nil)
+		}
+		notifyDeadlineMissed(data.MetadataUri, pending, "skipped", fmt.Sprintf("%s was skipped with %v left until the proof deadline", data.MetadataUri, timeLeft.Round(time.Second)))
+		challenges.markDone(data.MetadataUri)
+		proofRechecks.Delete(data.MetadataUri)
+		return time.Time{}
+	}
+
+	ready := []*duty{}
+	for _, d := range pending {
+		if readyForAttempt(d, data.MetadataUri) {
+			ready = append(ready, d)
+		}
+	}
+	if len(ready) == 0 {
+		return time.Time{}
+	}
+
+	log.Info().Msgf("Proving challenging data: %s for %d validators, %v left", data.MetadataUri, len(ready), timeLeft.Round(time.Second))
+	start := time.Now()
+	outcomes := submitProofs(data, ready)
+	if recheckAt := recheckTime(outcomes); !recheckAt.IsZero() {
+		return recheckAt
+	}
+	if !allProved(outcomes) || len(ready) < len(pending) {
+		return time.Time{}
+	}
+	challenges.markDone(data.MetadataUri)
+	proofDurations.observe(time.Since(start))
+	proofDurationSeconds.Observe(time.Since(start).Seconds())
+
+	timeLeft = time.Until(job.deadline)
+	proofTimeLeftSeconds.Observe(max(timeLeft.Seconds(), 0))
+	nearMissMargin := context.Config.Validator.NearMissMargin
+	if nearMissMargin <= 0 {
+		nearMissMargin = defaultNearMissMargin
+	}
+	switch {
+	case timeLeft < 0:
+		log.Error().Msgf("Proof of %s was submitted %v after the deadline", data.MetadataUri, (-timeLeft).Round(time.Second))
+		deadlineMissedTotal.WithLabelValues("late").Inc()
+		notifyDeadlineMissed(data.MetadataUri, ready, "late", fmt.Sprintf("Proof of %s was submitted %v after the deadline", data.MetadataUri, (-timeLeft).Round(time.Second)))
+	case timeLeft < time.Duration(nearMissMargin)*time.Second:
+		log.Warn().Msgf("Proof of %s was submitted only %v before the deadline", data.MetadataUri, timeLeft.Round(time.Second))
+		deadlineNearMissTotal.Inc()
+	}
+	return time.Time{}
+}
+
+// needsProof tells if the validator of d still has to prove metadataUri. The validity proof is
+// only queried from on-chain when the local state cannot tell whether a proof was included.
+func needsProof(d *duty, metadataUri string) bool {
+	record, found, err := getRecord(d, metadataUri)
 	if err != nil {
-		log.Error().Msgf("Failed to get metadata: %s", err)
-		return false
+		log.Error().Msgf("Failed to read state of %s: %s", metadataUri, err)
 	}
-	metadata := datypes.Metadata{}
-	if err := metadata.Unmarshal(metadataBytes); err != nil {
-		log.Error().Msgf("Failed to decode metadata: %s", err)
-		return false
+	if found {
+		switch record.State {
+		case state.StateTxConfirmed, state.StateInvaliditySubmitted, state.StateDeadlineMissed:
+			return false
+		case state.StateSeen, state.StateShardsFetched, state.StateProofsGenerated:
+			// interrupted before any tx was sent
+			return true
+		}
 	}
 
-	if len(data.ShardDoubleHashes) != len(metadata.ShardUris) {
-		log.Error().Msgf("Incorrect shard data count: %d %d", len(data.ShardDoubleHashes), len(metadata.ShardUris))
+	_, err = context.QueryClient.ValidityProof(context.Ctx, &datypes.QueryValidityProofRequest{MetadataUri: metadataUri, ValidatorAddress: d.validatorAddress})
+	if err == nil {
+		recordState(d, metadataUri, state.StateTxConfirmed, "validity proof found on-chain", nil)
 		return false
 	}
+	if !found {
+		recordState(d, metadataUri, state.StateSeen, "", nil)
+	}
+	return true
+}
 
-	validShards := [][]byte{}
-	validShardIndexes := []int{}
+// SubmitProofTx proves the shards of data assigned to each validator of ds and records the outcome in the state.
+// It returns true if every validator proved the data.
+func SubmitProofTx(data datypes.PublishedData, ds []*duty) bool {
+	return allProved(submitProofs(data, ds))
+}
 
-	for index, doubleHash := range data.ShardDoubleHashes {
-		shardUri := metadata.ShardUris[index]
-		shardData, err := protocol.Retrieve(shardUri)
-		if err != nil {
-			log.Error().Msgf("Failed to get shard data: %s", err)
-			continue
+func allProved(outcomes []proofOutcome) bool {
+	for _, outcome := range outcomes {
+		if outcome.err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// recheckTime returns when the bad shards of the data of outcomes are rechecked, zero if they are not.
+func recheckTime(outcomes []proofOutcome) time.Time {
+	for _, outcome := range outcomes {
+		if !outcome.recheckAt.IsZero() {
+			return outcome.recheckAt
 		}
+	}
+	return time.Time{}
+}
 
-		doubleShardHash := base64.StdEncoding.EncodeToString(utils.DoubleHashMimc(shardData))
-		if doubleShardHash != base64.StdEncoding.EncodeToString(doubleHash) {
-			log.Error().Msgf("Incorrect shard data: %d", index)
+func submitProofs(data datypes.PublishedData, ds []*duty) []proofOutcome {
+	outcomes := proveData(data, ds, false)
+	for i, outcome := range outcomes {
+		if errors.Is(outcome.err, leader.ErrNotLeader) {
+			log.Info().Msgf("Proof of %s for %s is left to the new leader", data.MetadataUri, ds[i].validatorAddress)
+		} else if errors.Is(outcome.err, errRecheckPending) {
 			continue
+		} else if outcome.err != nil {
+			log.Error().Msgf("Failed to prove %s for %s: %s", data.MetadataUri, ds[i].validatorAddress, outcome.err)
+			recordFailure(ds[i], data.MetadataUri, outcome.err)
 		}
-		validShards = append(validShards, shardData)
-		validShardIndexes = append(validShardIndexes, index)
 	}
+	return outcomes
+}
 
-	DataShardCount := len(data.ShardDoubleHashes) - int(metadata.ParityShardCount)
+// errRecheckPending is the outcome of a data whose bad shards are rechecked before it is proved.
+var errRecheckPending = errors.New("bad shards are rechecked later")
 
-	if len(validShards) < DataShardCount {
-		log.Error().Msgf("Valid shard count less than DataShardCount: %d", len(validShards))
-		return false
+// proofOutcome is the result of proving a data for a validator.
+type proofOutcome struct {
+	requiredIndices []int64
+	indices         []int64
+	proofs          [][]byte
+	txHash          string
+	// recheckAt is when the data is to be proved again, with errRecheckPending.
+	recheckAt time.Time
+	err       error
+}
+
+// proveData downloads the shards assigned to the validators of ds once, and submits the proofs
+// of each validator. It returns the outcome of each validator in the order of ds. With dryRun,
+// the proofs are generated but bad shards are not retried and nothing is broadcast.
+func proveData(data datypes.PublishedData, ds []*duty, dryRun bool) []proofOutcome {
+	outcomes := make([]proofOutcome, len(ds))
+	failAll := func(err error) []proofOutcome {
+		for i := range outcomes {
+			outcomes[i].err = err
+		}
+		return outcomes
+	}
+
+	protocol, metadata, err := retrieveMetadata(data)
+	if err != nil {
+		return failAll(newProofError(failureRetrieval, err))
+	}
+	fetch := fetchShardHashes
+	// data is audited with report in its challenge period, when invalidity is accepted, and the
+	// audit was done before the bad shards were rechecked
+	if context.Config.Validator.ReconstructionAudit == auditFlag && !recheckPending(&proofRechecks, data.MetadataUri) {
+		if _, _, audited := runReconstructionAudit(protocol, data, metadata, ds); audited != nil {
+			fetch = audited.fetch
+		}
 	}
 
 	shardLength := len(metadata.ShardUris)
 	queryThresholdResponse, err := context.QueryClient.ZkpProofThreshold(context.Ctx, &datypes.QueryZkpProofThresholdRequest{ShardCount: uint64(shardLength)})
 	if err != nil {
-		log.Error().Msgf("Failed to query Threshold: %s", err)
-		return false
+		return failAll(newProofError(failureQuery, fmt.Errorf("failed to query Threshold: %w", err)))
 	}
 
 	threshold := queryThresholdResponse.Threshold
-	validatorAddress := context.Config.Validator.ValidatorAddress
-	validator, err := sdk.ValAddressFromBech32(validatorAddress)
-	if err != nil {
-		log.Error().Msgf("Failed to parse ValidatorAddress: %s %s", validatorAddress, err)
-		return false
+	for i, d := range ds {
+		outcomes[i].requiredIndices = datypes.ShardIndicesForValidator(d.validator, int64(threshold), int64(shardLength))
+	}
+
+	// the shards assigned to several validators are downloaded once
+	requiredIndices := make([][]int64, len(ds))
+	for i := range outcomes {
+		requiredIndices[i] = outcomes[i].requiredIndices
+	}
+	indices := unionIndices(requiredIndices)
+	if context.Config.Validator.FullAudit {
+		indices = allIndices(shardLength)
+	}
+	var shardHashes map[int64][]byte
+	if dryRun {
+		shardHashes, _ = fetch(protocol, metadata, data.ShardDoubleHashes, indices)
+	} else {
+		var evidence []state.ShardEvidence
+		var recheckAt time.Time
+		shardHashes, evidence, recheckAt = checkShards(&proofRechecks, data.MetadataUri, protocol, metadata, data.ShardDoubleHashes, indices, fetch)
+		if !recheckAt.IsZero() {
+			for i := range outcomes {
+				outcomes[i].recheckAt = recheckAt
+			}
+			return failAll(errRecheckPending)
+		}
+		// invalidity is only accepted in the challenge period, so the valid shards are proved
+		if len(evidence) > 0 {
+			log.Warn().Msgf("%d shards of challenging data %s stay bad, proving the valid shards", len(evidence), data.MetadataUri)
+		}
 	}
 
-	requiredIndices := datypes.ShardIndicesForValidator(validator, int64(threshold), int64(shardLength))
-	proofs := [][]byte{}
-	indices := []int64{}
+	if context.Config.Validator.FullAudit {
+		// verify that the data is recoverable from the valid shards
+		DataShardCount := len(data.ShardDoubleHashes) - int(metadata.ParityShardCount)
+		if len(shardHashes) < DataShardCount {
+			return failAll(newProofError(failureRetrieval, fmt.Errorf("valid shard count less than DataShardCount: %d", len(shardHashes))))
+		}
+	}
 
-	for _, index := range requiredIndices {
-		for i, validIndex := range validShardIndexes {
-			if index != int64(validIndex) {
+	provers := []int{}
+	for i, d := range ds {
+		if outcomes[i].err != nil {
+			continue
+		}
+		provable := 0
+		for _, index := range outcomes[i].requiredIndices {
+			if _, ok := shardHashes[index]; ok {
+				provable++
+			}
+		}
+		if provable == 0 && len(outcomes[i].requiredIndices) > 0 {
+			// the bad shards were reported in the challenge period
+			if record, found, _ := getRecord(d, data.MetadataUri); found && record.Invalidity != nil && record.Invalidity.TxHash != "" {
+				recordState(d, data.MetadataUri, state.StateInvaliditySubmitted, "no valid assigned shard to prove", nil)
 				continue
 			}
+			outcomes[i].err = newProofError(failureRetrieval, fmt.Errorf("no valid shard in the %d assigned shards", len(outcomes[i].requiredIndices)))
+			continue
+		}
+		recordState(d, data.MetadataUri, state.StateShardsFetched, "", nil)
+		provers = append(provers, i)
+	}
+	if len(provers) == 0 {
+		return outcomes
+	}
 
-			shardData := validShards[i]
-			shardHash := utils.HashMimc(shardData)
-			doubleShardHash := utils.HashMimc(shardHash)
-			proofBytes, ok := getShardProofBytes(shardHash, doubleShardHash)
-			if !ok {
-				log.Error().Msgf("Failed to generate shard proof: %s, indice: %d", data.MetadataUri, index)
-				return false
-			}
+	// a proof depends only on the shard, so a shard assigned to several validators is proved once
+	proveIndices := make([][]int64, 0, len(provers))
+	for _, i := range provers {
+		proveIndices = append(proveIndices, outcomes[i].requiredIndices)
+	}
+	proofs, err := proveShards(shardHashes, unionIndices(proveIndices))
+	if err != nil {
+		err = newProofError(failureProof, fmt.Errorf("failed to generate shard proof: %s, %w", data.MetadataUri, err))
+		for _, i := range provers {
+			outcomes[i].err = err
+		}
+		return outcomes
+	}
 
-			proofs = append(proofs, proofBytes)
-			indices = append(indices, index)
+	var wg sync.WaitGroup
+	for _, i := range provers {
+		outcome := &outcomes[i]
+		for _, index := range outcome.requiredIndices {
+			if proof, ok := proofs[index]; ok {
+				outcome.indices = append(outcome.indices, index)
+				outcome.proofs = append(outcome.proofs, proof)
+			}
 		}
+		recordState(ds[i], data.MetadataUri, state.StateProofsGenerated, "", func(r *state.Record) {
+			r.Indices = outcome.indices
+		})
+		if dryRun {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outcome.txHash, outcome.err = submitShardProofs(ds[i], data.MetadataUri, outcome.indices, outcome.proofs)
+		}()
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// retrieveMetadata connects to the data source of data and retrieves its metadata,
+// which must list a shard per shard double hash.
+func retrieveMetadata(data datypes.PublishedData) (protocols.Protocol, datypes.Metadata, error) {
+	peerAddrInfo, err := peer.AddrInfoFromString(data.DataSourceInfo)
+	if err == nil {
+		protocols.ConnectSwarm(*peerAddrInfo)
+	}
+	protocol, err := protocols.GetRetrieveProtocol(data.MetadataUri)
+	if err != nil {
+		return nil, datypes.Metadata{}, fmt.Errorf("failed to get protocol: %w", err)
+	}
+
+	// verify shard data
+	metadataBytes, err := protocol.Retrieve(data.MetadataUri)
+	if err != nil {
+		return nil, datypes.Metadata{}, fmt.Errorf("failed to get metadata: %w", err)
 	}
+	metadata := datypes.Metadata{}
+	if err := metadata.Unmarshal(metadataBytes); err != nil {
+		return nil, datypes.Metadata{}, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+
+	if len(data.ShardDoubleHashes) != len(metadata.ShardUris) {
+		return nil, datypes.Metadata{}, fmt.Errorf("incorrect shard data count: %d %d", len(data.ShardDoubleHashes), len(metadata.ShardUris))
+	}
+	return protocol, metadata, nil
+}
+
+// proveShards generates the proofs of the indices whose shard is valid, by index.
+func proveShards(shardHashes map[int64][]byte, indices []int64) (map[int64][]byte, error) {
+	prover, err := getProver()
+	if err != nil {
+		return nil, err
+	}
+	provedIndices, proofs, err := generateProofs(prover, shardHashes, indices)
+	if err != nil {
+		return nil, err
+	}
+	proofsByIndex := make(map[int64][]byte, len(provedIndices))
+	for i, index := range provedIndices {
+		proofsByIndex[index] = proofs[i]
+	}
+	return proofsByIndex, nil
+}
 
-	return submitValidityProof(data.MetadataUri, indices, proofs)
+// submitShardProofs submits the proofs of the validator of d and returns the tx hash.
+func submitShardProofs(d *duty, metadataUri string, indices []int64, proofs [][]byte) (string, error) {
+	recordState(d, metadataUri, state.StateTxSubmitted, "", nil)
+	txHash, err := submitValidityProof(d, metadataUri, indices, proofs)
+	if err != nil {
+		return "", newProofError(failureBroadcast, err)
+	}
+	recordState(d, metadataUri, state.StateTxConfirmed, "", func(r *state.Record) {
+		r.TxHash = txHash
+	})
+	recentActivity.submitted(d, metadataUri, indices, txHash)
+	return txHash, nil
 }
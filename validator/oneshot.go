@@ -0,0 +1,191 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/utils"
+)
+
+// ProofReport is the proofs of a metadata URI for a validator, created by `validator prove`.
+type ProofReport struct {
+	ValidatorAddress string   `json:"validator_address"`
+	RequiredIndices  []int64  `json:"required_indices"`
+	Indices          []int64  `json:"indices"`
+	Proofs           [][]byte `json:"proofs"`
+	TxHash           string   `json:"tx_hash,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+// ProveOnce proves metadataUri for the configured validators, or only for validatorAddress if
+// it is not empty, like the monitor does for a challenging data. With dryRun, the proofs are
+// generated but nothing is broadcast and the state is not changed.
+func ProveOnce(metadataUri string, validatorAddress string, dryRun bool) ([]ProofReport, error) {
+	if err := loadDuties(!dryRun); err != nil {
+		return nil, err
+	}
+	ds := []*duty{}
+	for _, d := range duties {
+		if validatorAddress == "" || d.validatorAddress == validatorAddress {
+			ds = append(ds, d)
+		}
+	}
+	if len(ds) == 0 {
+		return nil, fmt.Errorf("validator %s is not configured", validatorAddress)
+	}
+	if !dryRun {
+		for _, d := range ds {
+			if err := CheckDeputy(d.validatorAddress, d.deputyAddress); err != nil {
+				return nil, err
+			}
+		}
+		if err := openStateStore(); err != nil {
+			return nil, err
+		}
+	}
+	initProofLimits()
+
+	res, err := context.QueryClient.PublishedData(context.Ctx, &datypes.QueryPublishedDataRequest{MetadataUri: metadataUri})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query published data %s: %w", metadataUri, err)
+	}
+	if res.Data.Status != datypes.Status_STATUS_CHALLENGING {
+		log.Warn().Msgf("%s is %s, validity proofs are only accepted while it is challenging", metadataUri, res.Data.Status)
+	}
+
+	var outcomes []proofOutcome
+	if dryRun {
+		outcomes = proveData(res.Data, ds, true)
+	} else {
+		outcomes = submitProofs(res.Data, ds)
+		// a one-shot proof is not run by a data worker, so it waits for the rechecks of bad shards
+		for recheckAt := recheckTime(outcomes); !recheckAt.IsZero(); recheckAt = recheckTime(outcomes) {
+			log.Warn().Msgf("Rechecking the bad shards of %s in %v", metadataUri, time.Until(recheckAt).Round(time.Second))
+			time.Sleep(time.Until(recheckAt))
+			outcomes = submitProofs(res.Data, ds)
+		}
+	}
+	reports := make([]ProofReport, len(ds))
+	for i, outcome := range outcomes {
+		reports[i] = ProofReport{
+			ValidatorAddress: ds[i].validatorAddress,
+			RequiredIndices:  outcome.requiredIndices,
+			Indices:          outcome.indices,
+			Proofs:           outcome.proofs,
+			TxHash:           outcome.txHash,
+		}
+		if outcome.err != nil {
+			reports[i].Error = outcome.err.Error()
+		}
+	}
+	return reports, nil
+}
+
+// ShardCheck is the availability and hash validity of a shard.
+type ShardCheck struct {
+	Index     int64  `json:"index"`
+	ShardUri  string `json:"shard_uri"`
+	Available bool   `json:"available"`
+	Size      int    `json:"size"`
+	ValidHash bool   `json:"valid_hash"`
+	// AssignedTo are the configured validators that have to prove the shard.
+	AssignedTo []string `json:"assigned_to,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// DataCheck is the state of every shard of a published data, created by `validator check`.
+type DataCheck struct {
+	MetadataUri    string       `json:"metadata_uri"`
+	Status         string       `json:"status"`
+	ShardCount     int          `json:"shard_count"`
+	DataShardCount int          `json:"data_shard_count"`
+	Threshold      uint64       `json:"threshold"`
+	ValidShards    int          `json:"valid_shards"`
+	Recoverable    bool         `json:"recoverable"`
+	Shards         []ShardCheck `json:"shards"`
+}
+
+// CheckData retrieves every shard of metadataUri and checks it against its double hash.
+func CheckData(metadataUri string) (DataCheck, error) {
+	if err := loadDuties(false); err != nil {
+		log.Warn().Msgf("Shard assignments are not shown: %s", err)
+	}
+	initProofLimits()
+
+	res, err := context.QueryClient.PublishedData(context.Ctx, &datypes.QueryPublishedDataRequest{MetadataUri: metadataUri})
+	if err != nil {
+		return DataCheck{}, fmt.Errorf("failed to query published data %s: %w", metadataUri, err)
+	}
+	data := res.Data
+	protocol, metadata, err := retrieveMetadata(data)
+	if err != nil {
+		return DataCheck{}, err
+	}
+	shardCount := len(metadata.ShardUris)
+	thresholdRes, err := context.QueryClient.ZkpProofThreshold(context.Ctx, &datypes.QueryZkpProofThresholdRequest{ShardCount: uint64(shardCount)})
+	if err != nil {
+		return DataCheck{}, fmt.Errorf("failed to query Threshold: %w", err)
+	}
+
+	check := DataCheck{
+		MetadataUri:    metadataUri,
+		Status:         data.Status.String(),
+		ShardCount:     shardCount,
+		DataShardCount: shardCount - int(metadata.ParityShardCount),
+		Threshold:      thresholdRes.Threshold,
+		Shards:         make([]ShardCheck, shardCount),
+	}
+	for i, shardUri := range metadata.ShardUris {
+		check.Shards[i] = ShardCheck{Index: int64(i), ShardUri: shardUri}
+	}
+	for _, d := range duties {
+		for _, index := range datypes.ShardIndicesForValidator(d.validator, int64(thresholdRes.Threshold), int64(shardCount)) {
+			if index >= 0 && index < int64(shardCount) {
+				check.Shards[index].AssignedTo = append(check.Shards[index].AssignedTo, d.validatorAddress)
+			}
+		}
+	}
+
+	limit := shardSizeLimit(metadata)
+	var mu sync.Mutex
+	g := new(errgroup.Group)
+	g.SetLimit(fetchWorkers)
+	for i := range check.Shards {
+		shard := &check.Shards[i]
+		g.Go(func() error {
+			if err := shardMemory.Acquire(context.Ctx, limit); err != nil {
+				return err
+			}
+			defer shardMemory.Release(limit)
+
+			shardData, err := protocol.RetrieveLimited(shard.ShardUri, limit)
+			if err != nil {
+				shard.Error = err.Error()
+				return nil
+			}
+			shard.Available = true
+			shard.Size = len(shardData)
+			shard.ValidHash = bytes.Equal(utils.HashMimc(utils.HashMimc(shardData)), data.ShardDoubleHashes[shard.Index])
+			if !shard.ValidHash {
+				shard.Error = "incorrect shard double hash"
+				return nil
+			}
+			mu.Lock()
+			check.ValidShards++
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return DataCheck{}, err
+	}
+	check.Recoverable = check.ValidShards >= check.DataShardCount
+	return check, nil
+}
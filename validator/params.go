@@ -0,0 +1,37 @@
+package validator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+
+	"github.com/sunriselayer/sunrise-data/context"
+)
+
+// paramsCacheDuration is how long the DA params are reused before they are queried again.
+const paramsCacheDuration = time.Minute
+
+var (
+	paramsMu        sync.Mutex
+	cachedParams    *datypes.Params
+	paramsFetchedAt time.Time
+)
+
+// daParams returns the DA params, queried at most once per paramsCacheDuration.
+func daParams() (datypes.Params, error) {
+	paramsMu.Lock()
+	defer paramsMu.Unlock()
+
+	if cachedParams != nil && time.Since(paramsFetchedAt) < paramsCacheDuration {
+		return *cachedParams, nil
+	}
+	res, err := context.QueryClient.Params(context.Ctx, &datypes.QueryParamsRequest{})
+	if err != nil {
+		return datypes.Params{}, fmt.Errorf("failed to query params: %w", err)
+	}
+	cachedParams = &res.Params
+	paramsFetchedAt = time.Now()
+	return res.Params, nil
+}
@@ -0,0 +1,249 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/protocols"
+	"github.com/sunriselayer/sunrise-data/utils"
+	"github.com/sunriselayer/sunrise-data/validator/state"
+)
+
+// maxPrefetchQueue is the number of published data waiting to be pre-fetched before new ones are dropped.
+const maxPrefetchQueue = 1000
+
+var (
+	// prefetchQueue feeds the newly published data to the pre-fetch worker.
+	prefetchQueue chan datypes.PublishedData
+	// prefetching holds the metadata URIs queued or being pre-fetched.
+	prefetching sync.Map
+)
+
+// startPrefetching starts the worker that pins shards of newly published data with prefetch_shards.
+func startPrefetching() {
+	if context.Config.Validator.PrefetchShards <= 0 {
+		return
+	}
+	if pins, err := stateStore.Pins(); err != nil {
+		log.Error().Msgf("Failed to read pins: %s", err)
+	} else {
+		pinnedData.Set(float64(len(pins)))
+	}
+	log.Info().Msgf("Up to %d shards of each published data are pinned until it is verified", context.Config.Validator.PrefetchShards)
+
+	prefetchQueue = make(chan datypes.PublishedData, maxPrefetchQueue)
+	go func() {
+		for data := range prefetchQueue {
+			if err := prefetchData(data); err != nil {
+				log.Warn().Msgf("Failed to pre-fetch %s: %s", data.MetadataUri, err)
+			}
+			prefetching.Delete(data.MetadataUri)
+		}
+	}()
+}
+
+// schedulePrefetch queues data in its challenge period to be pre-fetched, unless it is already queued.
+func schedulePrefetch(data datypes.PublishedData) {
+	if prefetchQueue == nil {
+		return
+	}
+	if _, loaded := prefetching.LoadOrStore(data.MetadataUri, struct{}{}); loaded {
+		return
+	}
+	select {
+	case prefetchQueue <- data:
+	default:
+		prefetching.Delete(data.MetadataUri)
+		log.Warn().Msgf("Pre-fetch queue is full, %s is fetched when challenged", data.MetadataUri)
+	}
+}
+
+// prefetchData pins the metadata and a sample of the shards of data on the node of its protocol,
+// so that they can still be proved if the publisher's node is gone when the data is challenged.
+func prefetchData(data datypes.PublishedData) error {
+	if _, found, err := stateStore.GetPin(data.MetadataUri); err != nil || found {
+		return err
+	}
+	protocol, metadata, err := retrieveMetadata(data)
+	if err != nil {
+		return err
+	}
+	pinner, ok := protocol.(protocols.Pinner)
+	if !ok {
+		log.Debug().Msgf("Shards of %s cannot be pinned", data.MetadataUri)
+		return nil
+	}
+	indices, err := prefetchIndices(metadata)
+	if err != nil {
+		return err
+	}
+	if err := pinner.Pin(data.MetadataUri); err != nil {
+		return fmt.Errorf("failed to pin metadata: %w", err)
+	}
+
+	limit := shardSizeLimit(metadata)
+	var mu sync.Mutex
+	pinned := []int64{}
+	g := new(errgroup.Group)
+	g.SetLimit(fetchWorkers)
+	for _, index := range indices {
+		shardUri := metadata.ShardUris[index]
+		g.Go(func() error {
+			if err := shardMemory.Acquire(context.Ctx, limit); err != nil {
+				return err
+			}
+			defer shardMemory.Release(limit)
+
+			shardData, err := protocol.RetrieveLimited(shardUri, limit)
+			if err != nil {
+				log.Debug().Msgf("Failed to pre-fetch shard %d of %s: %s", index, data.MetadataUri, err)
+				return nil
+			}
+			// a shard that does not match its double hash is not worth keeping
+			if !bytes.Equal(utils.HashMimc(utils.HashMimc(shardData)), data.ShardDoubleHashes[index]) {
+				log.Debug().Msgf("Incorrect pre-fetched shard %d of %s", index, data.MetadataUri)
+				return nil
+			}
+			if err := pinner.Pin(shardUri); err != nil {
+				log.Debug().Msgf("Failed to pin shard %d of %s: %s", index, data.MetadataUri, err)
+				return nil
+			}
+			mu.Lock()
+			pinned = append(pinned, index)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	pin := state.Pin{
+		MetadataUri: data.MetadataUri,
+		Uris:        []string{data.MetadataUri},
+		Indices:     pinned,
+		PinnedAt:    time.Now().UTC(),
+	}
+	for _, index := range pinned {
+		pin.Uris = append(pin.Uris, metadata.ShardUris[index])
+	}
+	if err := stateStore.SavePin(pin); err != nil {
+		return err
+	}
+	pinnedData.Inc()
+	prefetchedShardsTotal.Add(float64(len(pinned)))
+	log.Info().Msgf("Pinned %d of %d sampled shards of %s", len(pinned), len(indices), data.MetadataUri)
+	return nil
+}
+
+// prefetchIndices returns up to prefetch_shards shard indices to pre-fetch: the shards assigned
+// to the validators first, or every shard when they are all downloaded to prove, then random ones.
+func prefetchIndices(metadata datypes.Metadata) ([]int64, error) {
+	shardCount := len(metadata.ShardUris)
+	var indices []int64
+	if context.Config.Validator.FullAudit || context.Config.Validator.ReconstructionAudit != "" {
+		indices = allIndices(shardCount)
+	} else {
+		var err error
+		if indices, err = assignedIndices(shardCount); err != nil {
+			return nil, err
+		}
+	}
+
+	selected := map[int64]bool{}
+	for _, index := range indices {
+		selected[index] = true
+	}
+	for _, i := range rand.Perm(shardCount) {
+		if !selected[int64(i)] {
+			indices = append(indices, int64(i))
+		}
+	}
+	return indices[:min(len(indices), context.Config.Validator.PrefetchShards)], nil
+}
+
+// releasePins unpins what was pinned for metadataUri. The pin is kept with the URIs that
+// could not be unpinned, to be released again by the next sweep.
+func releasePins(metadataUri string) {
+	if stateStore == nil {
+		return
+	}
+	pin, found, err := stateStore.GetPin(metadataUri)
+	if err != nil {
+		log.Error().Msgf("Failed to read pin of %s: %s", metadataUri, err)
+		return
+	}
+	if !found {
+		return
+	}
+
+	remaining := []string{}
+	for _, uri := range pin.Uris {
+		protocol, err := protocols.GetRetrieveProtocol(uri)
+		if err != nil {
+			continue
+		}
+		pinner, ok := protocol.(protocols.Pinner)
+		if !ok {
+			continue
+		}
+		if err := pinner.Unpin(uri); err != nil {
+			log.Warn().Msgf("Failed to unpin %s of %s: %s", uri, metadataUri, err)
+			remaining = append(remaining, uri)
+		}
+	}
+	if len(remaining) > 0 {
+		pin.Uris = remaining
+		if err := stateStore.SavePin(pin); err != nil {
+			log.Error().Msgf("Failed to save pin of %s: %s", metadataUri, err)
+		}
+		return
+	}
+	if err := stateStore.RemovePin(metadataUri); err != nil {
+		log.Error().Msgf("Failed to remove pin of %s: %s", metadataUri, err)
+		return
+	}
+	pinnedData.Dec()
+	log.Info().Msgf("Released pins of %s", metadataUri)
+}
+
+// sweepPins releases the pins of data that was verified, rejected or removed since it was pinned,
+// for the status changes missed by the block events.
+func sweepPins() {
+	if stateStore == nil {
+		return
+	}
+	pins, err := stateStore.Pins()
+	if err != nil {
+		log.Error().Msgf("Failed to read pins: %s", err)
+		return
+	}
+	for _, pin := range pins {
+		res, err := context.QueryClient.PublishedData(context.Ctx, &datypes.QueryPublishedDataRequest{MetadataUri: pin.MetadataUri})
+		if err != nil {
+			// expired data is removed from the store
+			if grpcstatus.Code(err) == codes.NotFound {
+				releasePins(pin.MetadataUri)
+			}
+			continue
+		}
+		if pinReleasable(res.Data.Status) {
+			releasePins(pin.MetadataUri)
+		}
+	}
+}
+
+// pinReleasable returns true if the shards of data in status will not be proved anymore.
+func pinReleasable(status datypes.Status) bool {
+	return status == datypes.Status_STATUS_VERIFIED || status == datypes.Status_STATUS_REJECTED
+}
@@ -0,0 +1,217 @@
+package validator
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/rs/zerolog/log"
+	"github.com/sunriselayer/sunrise/x/da/zkp"
+)
+
+// provingKeyCacheDirName is the directory in state_dir where proving keys are cached.
+const provingKeyCacheDirName = "proving-keys"
+
+// prover generates validity proofs with a compiled circuit and a proving key,
+// and verifies them with the verifying key before they are submitted.
+type prover struct {
+	ccs          constraint.ConstraintSystem
+	provingKey   groth16.ProvingKey
+	verifyingKey groth16.VerifyingKey
+	// keyHash is the sha256 hash of the on-chain proving key.
+	keyHash string
+	// verifyingKeyHash is the sha256 hash of the on-chain verifying key.
+	verifyingKeyHash string
+}
+
+// errProofVerification is returned when a generated proof does not pass the local verification,
+// which means that the circuit of this binary does not match the keys in the DA params.
+var errProofVerification = errors.New("generated proof does not pass verification")
+
+var (
+	compileOnce  sync.Once
+	compiledCcs  constraint.ConstraintSystem
+	errCompile   error
+	proverMu     sync.Mutex
+	cachedProver *prover
+)
+
+// compileCircuit compiles the validity proof circuit into a R1CS once per process.
+func compileCircuit() (constraint.ConstraintSystem, error) {
+	compileOnce.Do(func() {
+		compiledCcs, errCompile = frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &zkp.ValidityProofCircuit{})
+	})
+	return compiledCcs, errCompile
+}
+
+// getProver returns the prover of the current DA params.
+// The proving key is reloaded only when the on-chain proving key changes.
+func getProver() (*prover, error) {
+	params, err := daParams()
+	if err != nil {
+		return nil, err
+	}
+	return proverForKey(params.ZkpProvingKey, params.ZkpVerifyingKey, provingKeyCacheDir())
+}
+
+// proverForKey returns the prover of the serialized provingKey and verifyingKey, loading the
+// proving key from cacheDir if possible. cacheDir may be empty to disable the disk cache.
+func proverForKey(provingKey []byte, verifyingKey []byte, cacheDir string) (*prover, error) {
+	hash := sha256.Sum256(provingKey)
+	keyHash := hex.EncodeToString(hash[:])
+	hash = sha256.Sum256(verifyingKey)
+	verifyingKeyHash := hex.EncodeToString(hash[:])
+
+	proverMu.Lock()
+	defer proverMu.Unlock()
+	if cachedProver != nil && cachedProver.keyHash == keyHash && cachedProver.verifyingKeyHash == verifyingKeyHash {
+		return cachedProver, nil
+	}
+
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(bytes.NewReader(verifyingKey)); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verifying key: %w", err)
+	}
+
+	ccs, err := compileCircuit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile circuit: %w", err)
+	}
+
+	pk, err := loadCachedProvingKey(cacheDir, keyHash)
+	if err != nil {
+		log.Info().Msgf("Loading proving key %s", keyHash)
+		if pk, err = zkp.UnmarshalProvingKey(provingKey); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal proving key: %w", err)
+		}
+		if err := saveCachedProvingKey(cacheDir, keyHash, pk); err != nil {
+			log.Warn().Msgf("Failed to cache proving key: %s", err)
+		}
+	}
+
+	cachedProver = &prover{
+		ccs:              ccs,
+		provingKey:       pk,
+		verifyingKey:     vk,
+		keyHash:          keyHash,
+		verifyingKeyHash: verifyingKeyHash,
+	}
+	return cachedProver, nil
+}
+
+// prove generates the validity proof of a shard and verifies it against the public witness.
+func (p *prover) prove(shardHash []byte, shardDoubleHash []byte) ([]byte, error) {
+	// witness definition
+	assignment := zkp.ValidityProofCircuit{
+		ShardHash:       shardHash,
+		ShardDoubleHash: shardDoubleHash,
+	}
+	witness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := groth16.Prove(p.ccs, p.provingKey, witness)
+	if err != nil {
+		return nil, err
+	}
+
+	// the chain verifies the proof with the shard double hash only
+	publicWitness, err := witness.Public()
+	if err != nil {
+		return nil, err
+	}
+	if err := groth16.Verify(proof, p.verifyingKey, publicWitness); err != nil {
+		return nil, fmt.Errorf("%w: %s", errProofVerification, err)
+	}
+
+	var b bytes.Buffer
+	bufWrite := bufio.NewWriter(&b)
+	if _, err := proof.WriteTo(bufWrite); err != nil {
+		return nil, err
+	}
+	if err := bufWrite.Flush(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func provingKeyCacheDir() string {
+	if stateStore == nil {
+		return ""
+	}
+	return filepath.Join(stateStore.Dir(), provingKeyCacheDirName)
+}
+
+// loadCachedProvingKey reads a proving key written by saveCachedProvingKey.
+// The raw encoding skips the point checks, which is only safe because the file is written by this process.
+func loadCachedProvingKey(cacheDir string, keyHash string) (groth16.ProvingKey, error) {
+	if cacheDir == "" {
+		return nil, os.ErrNotExist
+	}
+	f, err := os.Open(filepath.Join(cacheDir, keyHash))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pk := groth16.NewProvingKey(ecc.BN254)
+	if _, err := pk.UnsafeReadFrom(bufio.NewReader(f)); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+func saveCachedProvingKey(cacheDir string, keyHash string, pk groth16.ProvingKey) error {
+	if cacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(cacheDir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if _, err := pk.WriteRawTo(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(cacheDir, keyHash)); err != nil {
+		return err
+	}
+
+	// keys of previous params are not used anymore
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() != keyHash {
+			os.Remove(filepath.Join(cacheDir, entry.Name()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/sunriselayer/sunrise/x/da/zkp"
+
+	"github.com/sunriselayer/sunrise-data/utils"
+)
+
+// setupKeys returns serialized keys like ZkpProvingKey and ZkpVerifyingKey in the DA params.
+func setupKeys(b testing.TB) ([]byte, []byte) {
+	ccs, err := compileCircuit()
+	if err != nil {
+		b.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var pkBuf, vkBuf bytes.Buffer
+	if _, err := pk.WriteTo(&pkBuf); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := vk.WriteTo(&vkBuf); err != nil {
+		b.Fatal(err)
+	}
+	return pkBuf.Bytes(), vkBuf.Bytes()
+}
+
+func benchmarkShardHashes() ([]byte, []byte) {
+	shardHash := utils.HashMimc([]byte("benchmark shard"))
+	return shardHash, utils.HashMimc(shardHash)
+}
+
+// TestProveWithMismatchedVerifyingKey checks that a proof failing the local verification,
+// here against the verifying key of another setup, is never returned.
+func TestProveWithMismatchedVerifyingKey(t *testing.T) {
+	provingKey, verifyingKey := setupKeys(t)
+	_, otherVerifyingKey := setupKeys(t)
+	shardHash, shardDoubleHash := benchmarkShardHashes()
+	cachedProver = nil
+	t.Cleanup(func() { cachedProver = nil })
+
+	p, err := proverForKey(provingKey, verifyingKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.prove(shardHash, shardDoubleHash); err != nil {
+		t.Fatalf("proof with the verifying key of the setup: %s", err)
+	}
+
+	cachedProver = nil
+	p, err = proverForKey(provingKey, otherVerifyingKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := p.prove(shardHash, shardDoubleHash)
+	if !errors.Is(err, errProofVerification) {
+		t.Fatalf("expected %v, got %v", errProofVerification, err)
+	}
+	if proof != nil {
+		t.Fatalf("got %d proof bytes with a failed verification", len(proof))
+	}
+}
+
+// BenchmarkProveUncached compiles the circuit and unmarshals the proving key for every proof.
+func BenchmarkProveUncached(b *testing.B) {
+	provingKey, verifyingKey := setupKeys(b)
+	shardHash, shardDoubleHash := benchmarkShardHashes()
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(bytes.NewReader(verifyingKey)); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &zkp.ValidityProofCircuit{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		pk, err := zkp.UnmarshalProvingKey(provingKey)
+		if err != nil {
+			b.Fatal(err)
+		}
+		p := &prover{ccs: ccs, provingKey: pk, verifyingKey: vk}
+		if _, err := p.prove(shardHash, shardDoubleHash); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkProveCached reuses the prover while the proving key does not change.
+func BenchmarkProveCached(b *testing.B) {
+	provingKey, verifyingKey := setupKeys(b)
+	shardHash, shardDoubleHash := benchmarkShardHashes()
+	cachedProver = nil
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p, err := proverForKey(provingKey, verifyingKey, "")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := p.prove(shardHash, shardDoubleHash); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadProvingKeyFromDisk measures a restart, where the proving key is read from the disk cache.
+func BenchmarkLoadProvingKeyFromDisk(b *testing.B) {
+	provingKey, verifyingKey := setupKeys(b)
+	cacheDir := b.TempDir()
+	cachedProver = nil
+	if _, err := proverForKey(provingKey, verifyingKey, cacheDir); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cachedProver = nil
+		if _, err := proverForKey(provingKey, verifyingKey, cacheDir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
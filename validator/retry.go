@@ -0,0 +1,137 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/validator/state"
+)
+
+// failureKind tells apart the causes of a failed proof attempt, which are retried with different backoffs.
+type failureKind string
+
+const (
+	failureRetrieval failureKind = "retrieval"
+	failureQuery     failureKind = "query"
+	failureProof     failureKind = "proof"
+	failureBroadcast failureKind = "broadcast"
+	failureConfig    failureKind = "config"
+
+	defaultRetryMaxAttempts = 5
+	defaultRetryMaxBackoff  = 600
+)
+
+// defaultRetryBackoffs are the backoffs in seconds after the first failure of each kind.
+// Retrieval waits longer since shards often become available after a while.
+var defaultRetryBackoffs = map[failureKind]int{
+	failureRetrieval: 30,
+	failureQuery:     10,
+	failureProof:     10,
+	failureBroadcast: 15,
+	failureConfig:    60,
+}
+
+// proofError is an error of a proof attempt with its kind.
+type proofError struct {
+	kind failureKind
+	err  error
+}
+
+func newProofError(kind failureKind, err error) error {
+	return &proofError{kind: kind, err: err}
+}
+
+func (e *proofError) Error() string {
+	return fmt.Sprintf("%s: %s", e.kind, e.err)
+}
+
+func (e *proofError) Unwrap() error {
+	return e.err
+}
+
+func failureKindOf(err error) failureKind {
+	var proofErr *proofError
+	if errors.As(err, &proofErr) {
+		return proofErr.kind
+	}
+	return failureQuery
+}
+
+// retryBackoff returns the exponential backoff after failures attempts failed, the last one with kind.
+func retryBackoff(kind failureKind, failures int) time.Duration {
+	conf := context.Config.Validator
+	base := map[failureKind]int{
+		failureRetrieval: conf.RetryBackoffRetrieval,
+		failureQuery:     conf.RetryBackoffQuery,
+		failureProof:     conf.RetryBackoffProof,
+		failureBroadcast: conf.RetryBackoffBroadcast,
+	}[kind]
+	if base <= 0 {
+		base = defaultRetryBackoffs[kind]
+	}
+	maxBackoff := conf.RetryMaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+
+	backoff := time.Duration(base) * time.Second
+	for i := 1; i < failures && backoff < time.Duration(maxBackoff)*time.Second; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, time.Duration(maxBackoff)*time.Second)
+	// jitter so that data failing at once are not retried at once
+	return backoff + time.Duration(rand.Int63n(int64(backoff)/10+1))
+}
+
+// recordFailure records a failed proof attempt for the validator of d and schedules the next one,
+// or moves the record to the dead letters after retry_max_attempts failures.
+func recordFailure(d *duty, metadataUri string, err error) {
+	maxAttempts := context.Config.Validator.RetryMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryMaxAttempts
+	}
+	kind := failureKindOf(err)
+	recentActivity.failed(d, metadataUri, kind, err)
+
+	record, _, _ := getRecord(d, metadataUri)
+	failures := record.Failures + 1
+	if failures >= maxAttempts {
+		log.Error().Msgf("Giving up %s of %s after %d failed attempts, replay it with `sunrise-data validator replay %s`", metadataUri, d.validatorAddress, failures, metadataUri)
+		recordState(d, metadataUri, state.StateDeadLetter, err.Error(), func(r *state.Record) {
+			r.Failures = failures
+			r.FailureKind = string(kind)
+			r.NextAttemptAt = time.Time{}
+		})
+		notifyProofFailed(d, metadataUri, kind, failures, err)
+		return
+	}
+
+	backoff := retryBackoff(kind, failures)
+	log.Info().Msgf("Retrying %s of %s in %v after %s failure %d/%d", metadataUri, d.validatorAddress, backoff.Round(time.Second), kind, failures, maxAttempts)
+	recordState(d, metadataUri, state.StateFailed, err.Error(), func(r *state.Record) {
+		r.Failures = failures
+		r.FailureKind = string(kind)
+		r.NextAttemptAt = time.Now().UTC().Add(backoff)
+	})
+}
+
+// readyForAttempt tells if metadataUri can be proved for the validator of d, that is it is
+// not a dead letter and its retry backoff has passed.
+func readyForAttempt(d *duty, metadataUri string) bool {
+	record, found, err := getRecord(d, metadataUri)
+	if err != nil || !found {
+		return true
+	}
+	switch record.State {
+	case state.StateDeadLetter:
+		return false
+	case state.StateFailed:
+		return !time.Now().Before(record.NextAttemptAt)
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pin is the metadata and shards of a published data pinned before it was challenged.
+type Pin struct {
+	MetadataUri string `json:"metadata_uri"`
+	// Uris are the pinned metadata and shard URIs.
+	Uris []string `json:"uris"`
+	// Indices are the indices of the pinned shards.
+	Indices  []int64   `json:"indices"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// GetPin returns the pin of metadataUri. It returns false if nothing is pinned for it.
+func (s *Store) GetPin(metadataUri string) (Pin, bool, error) {
+	bz, err := os.ReadFile(s.pinPath(metadataUri))
+	if errors.Is(err, os.ErrNotExist) {
+		return Pin{}, false, nil
+	}
+	if err != nil {
+		return Pin{}, false, err
+	}
+	var pin Pin
+	if err := json.Unmarshal(bz, &pin); err != nil {
+		return Pin{}, false, fmt.Errorf("failed to decode pin of %s: %w", metadataUri, err)
+	}
+	return pin, true, nil
+}
+
+// SavePin saves pin, replacing the previous pin of its metadata URI.
+func (s *Store) SavePin(pin Pin) error {
+	bz, err := json.MarshalIndent(pin, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.pinPath(pin.MetadataUri), bz)
+}
+
+// RemovePin removes the pin of metadataUri.
+func (s *Store) RemovePin(metadataUri string) error {
+	err := os.Remove(s.pinPath(metadataUri))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Pins returns every pin, oldest first.
+func (s *Store) Pins() ([]Pin, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, pinsDirName))
+	if err != nil {
+		return nil, err
+	}
+
+	pins := []Pin{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		bz, err := os.ReadFile(filepath.Join(s.dir, pinsDirName, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var pin Pin
+		if err := json.Unmarshal(bz, &pin); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", entry.Name(), err)
+		}
+		pins = append(pins, pin)
+	}
+	sort.Slice(pins, func(i, j int) bool {
+		return pins[i].PinnedAt.Before(pins[j].PinnedAt)
+	})
+	return pins, nil
+}
+
+func (s *Store) pinPath(metadataUri string) string {
+	hash := sha256.Sum256([]byte(metadataUri))
+	return filepath.Join(s.dir, pinsDirName, hex.EncodeToString(hash[:])+".json")
+}
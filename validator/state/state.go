@@ -0,0 +1,363 @@
+// Package state stores the proof lifecycle of each metadata URI handled by the validators,
+// so that a restarted validator resumes where it stopped instead of repeating work.
+//
+// Every record is a JSON file written atomically, which lets other processes such as
+// the `validator status` command read the store while the validator is running.
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// State is a step of the proof lifecycle of a metadata URI.
+type State string
+
+const (
+	StateSeen            State = "seen"
+	StateShardsFetched   State = "shards_fetched"
+	StateProofsGenerated State = "proofs_generated"
+	StateTxSubmitted     State = "tx_submitted"
+	StateTxConfirmed     State = "tx_confirmed"
+	StateFailed          State = "failed"
+	// StateInvaliditySubmitted is the final state when no assigned shard was valid to prove.
+	StateInvaliditySubmitted State = "invalidity_submitted"
+	// StateDeadlineMissed is the final state when the proof could not be submitted before the end of the proof period.
+	StateDeadlineMissed State = "deadline_missed"
+	// StateDeadLetter is set when the proof failed max attempts times. It is retried only when replayed.
+	StateDeadLetter State = "dead_letter"
+)
+
+const (
+	recordsDirName     = "records"
+	pinsDirName        = "pins"
+	lastHeightFileName = "last_height"
+
+	// maxHistory is the number of transitions kept per record.
+	maxHistory = 20
+)
+
+// Transition is a state change of a record.
+type Transition struct {
+	State  State     `json:"state"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// Record is the lifecycle of a metadata URI for a validator.
+type Record struct {
+	ValidatorAddress string `json:"validator_address,omitempty"`
+	MetadataUri      string `json:"metadata_uri"`
+	State            State  `json:"state"`
+	Reason           string `json:"reason,omitempty"`
+	// Failures is the number of failed proof attempts.
+	Failures int `json:"failures"`
+	// FailureKind is the kind of the last failure, which decides the retry backoff.
+	FailureKind string `json:"failure_kind,omitempty"`
+	// NextAttemptAt is the earliest time of the next proof attempt after a failure.
+	NextAttemptAt time.Time    `json:"next_attempt_at,omitzero"`
+	Indices       []int64      `json:"indices,omitempty"`
+	TxHash        string       `json:"tx_hash,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	History       []Transition `json:"history,omitempty"`
+	// Invalidity is set when bad shards were found.
+	Invalidity *Invalidity `json:"invalidity,omitempty"`
+	// Audit is set when the data was reconstructed from its shards.
+	Audit *Audit `json:"audit,omitempty"`
+}
+
+// Audit is the result of reconstructing a data from its shards and checking it against its metadata.
+type Audit struct {
+	// Problems are the inconsistencies found, none if the data is consistent.
+	Problems  []string  `json:"problems,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// ShardEvidence is why a shard was found bad.
+type ShardEvidence struct {
+	Index              int64  `json:"index"`
+	ShardUri           string `json:"shard_uri"`
+	ExpectedDoubleHash []byte `json:"expected_double_hash"`
+	// ActualDoubleHash is the double hash of the retrieved shard, empty if it could not be retrieved.
+	ActualDoubleHash []byte    `json:"actual_double_hash,omitempty"`
+	Error            string    `json:"error"`
+	Attempts         int       `json:"attempts"`
+	CheckedAt        time.Time `json:"checked_at"`
+}
+
+// Invalidity is the MsgSubmitInvalidity decided for a metadata URI and its evidence.
+type Invalidity struct {
+	Indices  []int64         `json:"indices"`
+	Evidence []ShardEvidence `json:"evidence"`
+	TxHash   string          `json:"tx_hash,omitempty"`
+	// Error is set when the tx failed.
+	Error     string    `json:"error,omitempty"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// Final tells if the validator has nothing left to do for the record.
+func (r Record) Final() bool {
+	switch r.State {
+	case StateTxConfirmed, StateInvaliditySubmitted, StateDeadlineMissed:
+		return true
+	}
+	return false
+}
+
+// Store is a file based store of records.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Open opens the store in dir, creating it if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, recordsDirName), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state dir %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, pinsDirName), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state dir %s: %w", dir, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get returns the record of metadataUri for validatorAddress. It returns false if there is no record.
+func (s *Store) Get(validatorAddress string, metadataUri string) (Record, bool, error) {
+	bz, err := os.ReadFile(s.recordPath(validatorAddress, metadataUri))
+	if errors.Is(err, os.ErrNotExist) {
+		return Record{}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, err
+	}
+	var record Record
+	if err := json.Unmarshal(bz, &record); err != nil {
+		return Record{}, false, fmt.Errorf("failed to decode state of %s: %w", metadataUri, err)
+	}
+	return record, true, nil
+}
+
+// Transition moves the record of metadataUri for validatorAddress to state, creating the record if needed.
+// update, if not nil, can set other fields of the record before it is saved.
+func (s *Store) Transition(validatorAddress string, metadataUri string, state State, reason string, update func(*Record)) (Record, error) {
+	return s.Update(validatorAddress, metadataUri, transition(state, reason, update))
+}
+
+// transition returns the update moving a record to state.
+func transition(state State, reason string, update func(*Record)) func(*Record) {
+	return func(record *Record) {
+		record.State = state
+		record.Reason = reason
+		record.History = append(record.History, Transition{State: state, Reason: reason, At: record.UpdatedAt})
+		if len(record.History) > maxHistory {
+			record.History = record.History[len(record.History)-maxHistory:]
+		}
+		if update != nil {
+			update(record)
+		}
+	}
+}
+
+// Update sets fields of the record of metadataUri for validatorAddress without changing its state,
+// creating the record if needed.
+func (s *Store) Update(validatorAddress string, metadataUri string, update func(*Record)) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.update(validatorAddress, metadataUri, update)
+}
+
+// update is Update with s.mu held.
+func (s *Store) update(validatorAddress string, metadataUri string, update func(*Record)) (Record, error) {
+	record, found, err := s.Get(validatorAddress, metadataUri)
+	if err != nil {
+		return Record{}, err
+	}
+	now := time.Now().UTC()
+	if !found {
+		record = Record{
+			ValidatorAddress: validatorAddress,
+			MetadataUri:      metadataUri,
+			CreatedAt:        now,
+		}
+	}
+	record.UpdatedAt = now
+	update(&record)
+
+	bz, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return Record{}, err
+	}
+	return record, writeFileAtomic(s.recordPath(validatorAddress, metadataUri), bz)
+}
+
+// List returns every record, most recently updated first.
+func (s *Store) List() ([]Record, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, recordsDirName))
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		bz, err := os.ReadFile(filepath.Join(s.dir, recordsDirName, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var record Record
+		if err := json.Unmarshal(bz, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", entry.Name(), err)
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].UpdatedAt.After(records[j].UpdatedAt)
+	})
+	return records, nil
+}
+
+// Replay resets a dead letter or failed record so that the validator proves it again.
+// The state is checked and reset under the lock, so that a concurrent transition is not overwritten.
+func (s *Store) Replay(validatorAddress string, metadataUri string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, found, err := s.Get(validatorAddress, metadataUri)
+	if err != nil {
+		return Record{}, err
+	}
+	if !found {
+		return Record{}, fmt.Errorf("no state for %s of %s", metadataUri, validatorAddress)
+	}
+	if record.State != StateDeadLetter && record.State != StateFailed {
+		return Record{}, fmt.Errorf("%s is %s, only %s and %s can be replayed", metadataUri, record.State, StateDeadLetter, StateFailed)
+	}
+	return s.update(validatorAddress, metadataUri, transition(StateSeen, "replayed", func(r *Record) {
+		r.Failures = 0
+		r.FailureKind = ""
+		r.NextAttemptAt = time.Time{}
+	}))
+}
+
+// Prune removes the records last updated before before for which prunable returns true,
+// and returns the number of removed records.
+func (s *Store) Prune(before time.Time, prunable func(Record) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, record := range records {
+		if !record.UpdatedAt.Before(before) || !prunable(record) {
+			continue
+		}
+		err := os.Remove(s.recordPath(record.ValidatorAddress, record.MetadataUri))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// LastHeight returns the last block height processed by the validator, or 0 if unknown.
+func (s *Store) LastHeight() (int64, error) {
+	bz, err := os.ReadFile(filepath.Join(s.dir, lastHeightFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(bz)), 10, 64)
+}
+
+// SetLastHeight saves the last block height processed by the validator.
+func (s *Store) SetLastHeight(height int64) error {
+	return writeFileAtomic(filepath.Join(s.dir, lastHeightFileName), []byte(strconv.FormatInt(height, 10)))
+}
+
+// AdoptLegacy assigns the records saved before the store was keyed by validator to validatorAddress.
+// It returns the number of adopted records.
+func (s *Store) AdoptLegacy(validatorAddress string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	adopted := 0
+	for _, record := range records {
+		if record.ValidatorAddress != "" {
+			continue
+		}
+		record.ValidatorAddress = validatorAddress
+		bz, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return adopted, err
+		}
+		if err := writeFileAtomic(s.recordPath(validatorAddress, record.MetadataUri), bz); err != nil {
+			return adopted, err
+		}
+		if err := os.Remove(s.recordPath("", record.MetadataUri)); err != nil {
+			return adopted, err
+		}
+		adopted++
+	}
+	return adopted, nil
+}
+
+func (s *Store) recordPath(validatorAddress string, metadataUri string) string {
+	// metadata URIs contain characters that are not valid in file names
+	key := metadataUri
+	if validatorAddress != "" {
+		key = validatorAddress + "/" + metadataUri
+	}
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, recordsDirName, hex.EncodeToString(hash[:])+".json")
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to path,
+// so that readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,172 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+
+	"github.com/sunriselayer/sunrise-data/balance"
+	"github.com/sunriselayer/sunrise-data/leader"
+)
+
+const (
+	// maxRecentActivity is the number of submissions and failures kept for the status endpoint.
+	maxRecentActivity = 50
+	// maxFollowDelay is how long the block follower may not query the chain before the validator is unhealthy.
+	maxFollowDelay = 2 * time.Minute
+)
+
+// Submission is a MsgSubmitValidityProof included in a tx.
+type Submission struct {
+	MetadataUri      string    `json:"metadata_uri"`
+	ValidatorAddress string    `json:"validator_address"`
+	Indices          []int64   `json:"indices"`
+	TxHash           string    `json:"tx_hash"`
+	At               time.Time `json:"at"`
+}
+
+// Failure is a failed proof attempt.
+type Failure struct {
+	MetadataUri      string    `json:"metadata_uri"`
+	ValidatorAddress string    `json:"validator_address"`
+	Kind             string    `json:"kind"`
+	Error            string    `json:"error"`
+	At               time.Time `json:"at"`
+}
+
+// activityLog keeps the most recent submissions and failures, newest first.
+type activityLog struct {
+	mu          sync.Mutex
+	submissions []Submission
+	failures    []Failure
+}
+
+var recentActivity = &activityLog{}
+
+func (a *activityLog) submitted(d *duty, metadataUri string, indices []int64, txHash string) {
+	proofsSubmittedTotal.WithLabelValues(d.validatorAddress).Inc()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.submissions = append([]Submission{{
+		MetadataUri:      metadataUri,
+		ValidatorAddress: d.validatorAddress,
+		Indices:          indices,
+		TxHash:           txHash,
+		At:               time.Now().UTC(),
+	}}, a.submissions[:min(len(a.submissions), maxRecentActivity-1)]...)
+}
+
+func (a *activityLog) failed(d *duty, metadataUri string, kind failureKind, err error) {
+	proofFailuresTotal.WithLabelValues(d.validatorAddress, string(kind)).Inc()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.failures = append([]Failure{{
+		MetadataUri:      metadataUri,
+		ValidatorAddress: d.validatorAddress,
+		Kind:             string(kind),
+		Error:            err.Error(),
+		At:               time.Now().UTC(),
+	}}, a.failures[:min(len(a.failures), maxRecentActivity-1)]...)
+}
+
+func (a *activityLog) recent() ([]Submission, []Failure) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Submission{}, a.submissions...), append([]Failure{}, a.failures...)
+}
+
+// ValidatorPair is a validator proven by this process and its deputy.
+type ValidatorPair struct {
+	ValidatorAddress string `json:"validator_address"`
+	DeputyAddress    string `json:"deputy_address"`
+}
+
+// QueuedProof is a challenging data waiting for a data worker.
+type QueuedProof struct {
+	MetadataUri string    `json:"metadata_uri"`
+	Deadline    time.Time `json:"deadline"`
+	// RecheckAt is when the bad shards of the data are retried, if they are.
+	RecheckAt *time.Time `json:"recheck_at,omitempty"`
+}
+
+// Status is the state of the running validator served on /status.
+type Status struct {
+	Healthy             bool            `json:"healthy"`
+	Leader              bool            `json:"leader"`
+	LastProcessedHeight int64           `json:"last_processed_height"`
+	LastFollowedAt      time.Time       `json:"last_followed_at"`
+	Validators          []ValidatorPair `json:"validators"`
+	TrackedChallenges   int             `json:"tracked_challenges"`
+	Queue               []QueuedProof   `json:"queue"`
+	// EstimatedProofSeconds is the moving average of the time taken to prove a challenging data.
+	EstimatedProofSeconds float64          `json:"estimated_proof_seconds"`
+	RecentSubmissions     []Submission     `json:"recent_submissions"`
+	RecentFailures        []Failure        `json:"recent_failures"`
+	Balances              []balance.Status `json:"balances"`
+}
+
+// currentStatus returns the status of the running validator.
+func currentStatus() Status {
+	followedAt := time.Unix(lastFollowedAt.Load(), 0).UTC()
+	status := Status{
+		Healthy:               time.Since(followedAt) < maxFollowDelay,
+		Leader:                leader.IsLeader(),
+		LastProcessedHeight:   lastProcessedHeight.Load(),
+		LastFollowedAt:        followedAt,
+		Validators:            []ValidatorPair{},
+		TrackedChallenges:     challenges.len(),
+		Queue:                 []QueuedProof{},
+		EstimatedProofSeconds: proofDurations.get().Seconds(),
+		Balances:              balance.Statuses(),
+	}
+	for _, d := range duties {
+		status.Validators = append(status.Validators, ValidatorPair{ValidatorAddress: d.validatorAddress, DeputyAddress: d.deputyAddress})
+	}
+	for _, job := range proofQueue.snapshot() {
+		queued := QueuedProof{MetadataUri: job.data.MetadataUri, Deadline: job.deadline}
+		if !job.notBefore.IsZero() {
+			queued.RecheckAt = &job.notBefore
+		}
+		status.Queue = append(status.Queue, queued)
+	}
+	status.RecentSubmissions, status.RecentFailures = recentActivity.recent()
+	return status
+}
+
+// serveStatus serves the health, the status and the Prometheus metrics of the validator on port.
+func serveStatus(port int) {
+	r := mux.NewRouter()
+	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		status := currentStatus()
+		code := http.StatusOK
+		if !status.Healthy {
+			code = http.StatusServiceUnavailable
+		}
+		writeJson(w, code, struct {
+			Healthy             bool      `json:"healthy"`
+			LastProcessedHeight int64     `json:"last_processed_height"`
+			LastFollowedAt      time.Time `json:"last_followed_at"`
+		}{status.Healthy, status.LastProcessedHeight, status.LastFollowedAt})
+	}).Methods("GET")
+	r.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, currentStatus())
+	}).Methods("GET")
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	log.Info().Msgf("Running validator status server on localhost: %d", port)
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), r); err != nil {
+		log.Error().Msgf("Validator status server stopped: %s", err)
+	}
+}
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,119 @@
+package validator
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sunriselayer/sunrise-data/config"
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/validator/state"
+)
+
+const (
+	defaultStateDir       = "validator-state"
+	defaultStateRetention = 168
+)
+
+// stateStore keeps the proof lifecycle of each metadata URI and validator across restarts.
+var stateStore *state.Store
+
+// StateDir returns the state_dir of conf, or its default.
+func StateDir(conf config.Config) string {
+	if conf.Validator.StateDir != "" {
+		return conf.Validator.StateDir
+	}
+	return defaultStateDir
+}
+
+func openStateStore() error {
+	store, err := state.Open(StateDir(context.Config))
+	if err != nil {
+		return err
+	}
+	stateStore = store
+	log.Info().Msgf("Validator state is stored in %s", store.Dir())
+
+	// records saved by a version proving a single validator belong to the first validator
+	adopted, err := store.AdoptLegacy(duties[0].validatorAddress)
+	if err != nil {
+		return err
+	}
+	if adopted > 0 {
+		log.Info().Msgf("%d records are assigned to %s", adopted, duties[0].validatorAddress)
+	}
+	return nil
+}
+
+// recordState moves metadataUri to s for the validator of d. A failure is only logged,
+// since the state saves duplicate work but is not required to prove data.
+func recordState(d *duty, metadataUri string, s state.State, reason string, update func(*state.Record)) {
+	if stateStore == nil {
+		return
+	}
+	if _, err := stateStore.Transition(d.validatorAddress, metadataUri, s, reason, update); err != nil {
+		log.Error().Msgf("Failed to save state %s of %s: %s", s, metadataUri, err)
+	}
+}
+
+// updateRecord sets fields of the record of metadataUri for the validator of d without changing its state.
+func updateRecord(d *duty, metadataUri string, update func(*state.Record)) {
+	if stateStore == nil {
+		return
+	}
+	if _, err := stateStore.Update(d.validatorAddress, metadataUri, update); err != nil {
+		log.Error().Msgf("Failed to save state of %s: %s", metadataUri, err)
+	}
+}
+
+// getRecord returns the record of metadataUri for the validator of d.
+func getRecord(d *duty, metadataUri string) (state.Record, bool, error) {
+	if stateStore == nil {
+		return state.Record{}, false, nil
+	}
+	return stateStore.Get(d.validatorAddress, metadataUri)
+}
+
+// pruneRecords removes the records not updated for state_retention hours that are final, or whose
+// data is not in active, the metadata URIs in their challenge period or challenging.
+func pruneRecords(active map[string]bool) {
+	if stateStore == nil {
+		return
+	}
+	retention := context.Config.Validator.StateRetention
+	if retention <= 0 {
+		retention = defaultStateRetention
+	}
+	before := time.Now().Add(-time.Duration(retention) * time.Hour)
+	pruned, err := stateStore.Prune(before, func(record state.Record) bool {
+		return record.Final() || !active[record.MetadataUri]
+	})
+	if err != nil {
+		log.Error().Msgf("Failed to prune validator state: %s", err)
+	}
+	if pruned > 0 {
+		log.Info().Msgf("Pruned %d records older than %d hours", pruned, retention)
+	}
+}
+
+// savedHeight returns the last processed height saved by a previous run, or 0.
+func savedHeight() int64 {
+	if stateStore == nil {
+		return 0
+	}
+	height, err := stateStore.LastHeight()
+	if err != nil {
+		log.Error().Msgf("Failed to read last processed height: %s", err)
+		return 0
+	}
+	return height
+}
+
+func saveHeight(height int64) {
+	if stateStore == nil {
+		return
+	}
+	if err := stateStore.SetLastHeight(height); err != nil {
+		log.Error().Msgf("Failed to save last processed height %d: %s", height, err)
+	}
+}
@@ -2,25 +2,33 @@ package validator
 
 import (
 	"github.com/rs/zerolog/log"
+
 	"github.com/sunriselayer/sunrise-data/context"
-	datypes "github.com/sunriselayer/sunrise/x/da/types"
 )
 
 // RunValidatorTask is a function to run threads.
 func RunValidatorTask() bool {
 	log.Info().Msg("Starting validator task")
-	validatorAddress := context.Config.Validator.ValidatorAddress
-	deputyAddress := context.Addr
-	log.Info().Msgf("validator: %s deputy: %s", validatorAddress, deputyAddress)
-	res, err := context.QueryClient.ProofDeputy(context.Ctx, &datypes.QueryProofDeputyRequest{ValidatorAddress: validatorAddress})
-	if err != nil {
-		log.Error().Msgf("Failed to query proof deputy: %s", err)
-		log.Info().Msg("Please send a MsgRegisterProofDeputy tx from your validator address")
+	if err := loadDuties(true); err != nil {
+		log.Error().Msgf("Failed to load validators: %s", err)
+		return false
+	}
+	for _, d := range duties {
+		log.Info().Msgf("validator: %s deputy: %s", d.validatorAddress, d.deputyAddress)
+		if err := CheckDeputy(d.validatorAddress, d.deputyAddress); err != nil {
+			log.Error().Msgf("Failed to check proof deputy: %s", err)
+			log.Info().Msgf("Please run `sunrise-data validator register-deputy --validator %s` with your validator operator key", d.validatorAddress)
+			return false
+		}
+	}
+	switch audit := context.Config.Validator.ReconstructionAudit; audit {
+	case "", auditFlag, auditReport:
+	default:
+		log.Error().Msgf("Unsupported reconstruction_audit %q, use %q or %q", audit, auditFlag, auditReport)
 		return false
 	}
-	if res.DeputyAddress != deputyAddress {
-		log.Error().Msgf("%s is not registered as a proof deputy of %s", deputyAddress, validatorAddress)
-		log.Info().Msg("Please send a MsgRegisterProofDeputy tx from your validator address")
+	if err := openStateStore(); err != nil {
+		log.Error().Msgf("Failed to open validator state: %s", err)
 		return false
 	}
 	go Monitor()
@@ -0,0 +1,114 @@
+package validator
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+)
+
+// trackedChallenge is a published data in STATUS_CHALLENGING that the validator has to prove.
+type trackedChallenge struct {
+	Data       datypes.PublishedData
+	DetectedAt time.Time
+	// Done is set once the data is proven or cannot be proven anymore.
+	Done bool
+}
+
+// challengeTracker keeps the set of challenges found through block events and reconciliation sweeps.
+type challengeTracker struct {
+	mu    sync.RWMutex
+	items map[string]*trackedChallenge
+}
+
+var challenges = newChallengeTracker()
+
+func newChallengeTracker() *challengeTracker {
+	return &challengeTracker{
+		items: map[string]*trackedChallenge{},
+	}
+}
+
+// update adds data if it is challenging, and removes it otherwise.
+// It returns true if the data was not tracked before.
+func (t *challengeTracker) update(data datypes.PublishedData) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if data.Status != datypes.Status_STATUS_CHALLENGING {
+		delete(t.items, data.MetadataUri)
+		return false
+	}
+	if item, ok := t.items[data.MetadataUri]; ok {
+		item.Data = data
+		return false
+	}
+	t.items[data.MetadataUri] = &trackedChallenge{
+		Data:       data,
+		DetectedAt: time.Now(),
+	}
+	return true
+}
+
+// replace makes the tracked set equal to challenging, keeping the state of items already tracked.
+func (t *challengeTracker) replace(challenging map[string]datypes.PublishedData) (added int, removed int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for uri := range t.items {
+		if _, ok := challenging[uri]; !ok {
+			delete(t.items, uri)
+			removed++
+		}
+	}
+	for uri, data := range challenging {
+		if item, ok := t.items[uri]; ok {
+			item.Data = data
+			continue
+		}
+		t.items[uri] = &trackedChallenge{
+			Data:       data,
+			DetectedAt: time.Now(),
+		}
+		added++
+	}
+	return added, removed
+}
+
+// pending returns the tracked challenges that are not done yet, oldest first.
+func (t *challengeTracker) pending() []datypes.PublishedData {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	items := []*trackedChallenge{}
+	for _, item := range t.items {
+		if !item.Done {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DetectedAt.Before(items[j].DetectedAt)
+	})
+
+	pending := make([]datypes.PublishedData, len(items))
+	for i, item := range items {
+		pending[i] = item.Data
+	}
+	return pending
+}
+
+func (t *challengeTracker) markDone(metadataUri string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if item, ok := t.items[metadataUri]; ok {
+		item.Done = true
+	}
+}
+
+func (t *challengeTracker) len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.items)
+}
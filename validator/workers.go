@@ -0,0 +1,247 @@
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	datypes "github.com/sunriselayer/sunrise/x/da/types"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/sunriselayer/sunrise-data/context"
+	"github.com/sunriselayer/sunrise-data/protocols"
+	"github.com/sunriselayer/sunrise-data/utils"
+)
+
+const (
+	defaultDataWorkers       = 2
+	defaultFetchWorkers      = 8
+	defaultMaxShardMemoryMiB = 256
+)
+
+var (
+	// proofQueue feeds the challenging data to the data workers, closest deadline first.
+	proofQueue = newDeadlineQueue()
+	// inFlight holds the metadata URIs queued or being proved.
+	inFlight sync.Map
+
+	// proofSlots bounds the groth16 proofs generated at once across all data.
+	proofSlots chan struct{}
+	// shardMemory bounds the bytes of downloaded shards held at once across all data.
+	shardMemory    *semaphore.Weighted
+	maxShardMemory int64
+	fetchWorkers   int
+)
+
+// startProofWorkers starts the workers that prove challenging data concurrently.
+func startProofWorkers() {
+	dataWorkers := context.Config.Validator.DataWorkers
+	if dataWorkers <= 0 {
+		dataWorkers = defaultDataWorkers
+	}
+	initProofLimits()
+	log.Info().Msgf("Proof workers: %d data", dataWorkers)
+
+	for i := 0; i < dataWorkers; i++ {
+		go func() {
+			for {
+				job := proofQueue.pop()
+				if recheckAt := proveChallenge(job); !recheckAt.IsZero() {
+					// the data stays in flight, and the worker is free until its bad shards are rechecked
+					job.notBefore = recheckAt
+					proofQueue.push(job)
+					continue
+				}
+				inFlight.Delete(job.data.MetadataUri)
+			}
+		}()
+	}
+}
+
+// initProofLimits sets the bounds of the shard downloads and proofs shared by all data.
+// Defaults depend on the number of CPUs, since groth16 proofs are CPU bound.
+func initProofLimits() {
+	conf := context.Config.Validator
+
+	fetchWorkers = conf.FetchWorkers
+	if fetchWorkers <= 0 {
+		fetchWorkers = defaultFetchWorkers
+	}
+	proofWorkers := conf.ProofWorkers
+	if proofWorkers <= 0 {
+		// groth16.Prove is multi-threaded, so a proof per 2 CPUs keeps them busy
+		proofWorkers = max(runtime.NumCPU()/2, 1)
+	}
+	memoryMiB := conf.MaxShardMemoryMiB
+	if memoryMiB <= 0 {
+		memoryMiB = defaultMaxShardMemoryMiB
+	}
+	maxShardMemory = memoryMiB << 20
+
+	proofSlots = make(chan struct{}, proofWorkers)
+	shardMemory = semaphore.NewWeighted(maxShardMemory)
+	log.Info().Msgf("Proof limits: %d shard downloads per data, %d proofs, %d MiB of shards", fetchWorkers, proofWorkers, memoryMiB)
+}
+
+// enqueueProof queues data unless it is already queued or being proved.
+func enqueueProof(data datypes.PublishedData, deadline time.Time) {
+	if _, loaded := inFlight.LoadOrStore(data.MetadataUri, struct{}{}); loaded {
+		return
+	}
+	proofQueue.push(proofJob{data: data, deadline: deadline})
+}
+
+// shardSizeLimit is the most bytes read of a shard of metadata, which is also the memory reserved for it.
+// The shard size of the metadata is not trusted, so it is bounded by the max shard size of the params
+// and by max_shard_memory_mib; a shard over the limit is a bad shard.
+func shardSizeLimit(metadata datypes.Metadata) int64 {
+	limit := uint64(maxShardMemory)
+	if metadata.ShardSize != 0 {
+		limit = min(limit, metadata.ShardSize)
+	}
+	params, err := daParams()
+	if err != nil {
+		log.Warn().Msgf("Shard size limit of %d bytes is not bounded by the max shard size: %s", limit, err)
+		return int64(limit)
+	}
+	return int64(min(limit, params.MaxShardSize))
+}
+
+// shardFailure is why a shard was not valid.
+type shardFailure struct {
+	err error
+	// doubleHash is the double hash of the retrieved shard, nil if it could not be retrieved.
+	doubleHash []byte
+}
+
+// shardFetcher returns the hash of each valid shard of indices and the failure of the other shards, by index.
+type shardFetcher func(protocol protocols.Protocol, metadata datypes.Metadata, doubleHashes [][]byte, indices []int64) (map[int64][]byte, map[int64]shardFailure)
+
+// fetchShardHashes downloads the shards of indices concurrently and returns the hash of each
+// shard matching its double hash, and the failure of the other shards, by index. Shards are
+// released once hashed, so only the shards being downloaded are held in memory.
+func fetchShardHashes(protocol protocols.Protocol, metadata datypes.Metadata, doubleHashes [][]byte, indices []int64) (map[int64][]byte, map[int64]shardFailure) {
+	limit := shardSizeLimit(metadata)
+
+	var mu sync.Mutex
+	shardHashes := map[int64][]byte{}
+	failures := map[int64]shardFailure{}
+	fail := func(index int64, failure shardFailure) {
+		mu.Lock()
+		failures[index] = failure
+		mu.Unlock()
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(fetchWorkers)
+	for _, index := range indices {
+		if index < 0 || index >= int64(len(doubleHashes)) {
+			log.Error().Msgf("Shard index out of range: %d", index)
+			continue
+		}
+		shardUri := metadata.ShardUris[index]
+		doubleHash := doubleHashes[index]
+		g.Go(func() error {
+			if err := shardMemory.Acquire(context.Ctx, limit); err != nil {
+				return err
+			}
+			defer shardMemory.Release(limit)
+
+			shardData, err := protocol.RetrieveLimited(shardUri, limit)
+			if errors.Is(err, protocols.ErrTooLarge) {
+				log.Error().Msgf("Shard %d is larger than %d bytes", index, limit)
+				fail(index, shardFailure{err: fmt.Errorf("shard is larger than %d bytes: %w", limit, err)})
+				return nil
+			}
+			if err != nil {
+				log.Error().Msgf("Failed to get shard data: %s", err)
+				fail(index, shardFailure{err: fmt.Errorf("failed to get shard data: %w", err)})
+				return nil
+			}
+
+			shardHash := utils.HashMimc(shardData)
+			shardDoubleHash := utils.HashMimc(shardHash)
+			if !bytes.Equal(shardDoubleHash, doubleHash) {
+				log.Error().Msgf("Incorrect shard data: %d", index)
+				fail(index, shardFailure{err: errors.New("incorrect shard double hash"), doubleHash: shardDoubleHash})
+				return nil
+			}
+			mu.Lock()
+			shardHashes[index] = shardHash
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		log.Error().Msgf("Failed to get shard data: %s", err)
+	}
+	return shardHashes, failures
+}
+
+// generateProofs generates the proofs of the required indices whose shard is valid, concurrently
+// up to proof_workers across all data. The proofs are returned in the order of requiredIndices.
+func generateProofs(prover *prover, shardHashes map[int64][]byte, requiredIndices []int64) ([]int64, [][]byte, error) {
+	indices := []int64{}
+	for _, index := range requiredIndices {
+		if _, ok := shardHashes[index]; ok {
+			indices = append(indices, index)
+		}
+	}
+
+	proofs := make([][]byte, len(indices))
+	g := new(errgroup.Group)
+	for i, index := range indices {
+		g.Go(func() error {
+			proofSlots <- struct{}{}
+			defer func() { <-proofSlots }()
+
+			shardHash := shardHashes[index]
+			proofBytes, err := prover.prove(shardHash, utils.HashMimc(shardHash))
+			if errors.Is(err, errProofVerification) {
+				log.Error().Msgf("Proof of indice %d failed local verification, it is not submitted. The circuit of sunrise-data may not match the zkp keys in the DA params, please check the version: %s", index, err)
+				proofVerificationFailuresTotal.Inc()
+			}
+			if err != nil {
+				return fmt.Errorf("indice: %d: %w", index, err)
+			}
+			proofs[i] = proofBytes
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return indices, proofs, nil
+}
+
+func allIndices(shardCount int) []int64 {
+	indices := make([]int64, shardCount)
+	for i := range indices {
+		indices[i] = int64(i)
+	}
+	return indices
+}
+
+// unionIndices returns the indices in any of lists, in ascending order.
+func unionIndices(lists [][]int64) []int64 {
+	seen := map[int64]bool{}
+	indices := []int64{}
+	for _, list := range lists {
+		for _, index := range list {
+			if !seen[index] {
+				seen[index] = true
+				indices = append(indices, index)
+			}
+		}
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i] < indices[j]
+	})
+	return indices
+}